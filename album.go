@@ -0,0 +1,120 @@
+package tg
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	AlbumWindowDef = 2 * time.Second
+)
+
+// Album is a set of messages sharing the same media_group_id, ordered by message id.
+type Album struct {
+	MediaGroupId string
+	Chat         Chat
+	Messages     []Message
+}
+
+// AlbumDispatcher collects album messages arriving as separate updates
+// and delivers them as one Album after no new message of the same media group
+// has arrived for Window.
+// Updates without media_group_id are passed to HandleUpdate right away.
+// HandleAlbum is called from a separate goroutine.
+type AlbumDispatcher struct {
+	Window time.Duration
+
+	HandleUpdate func(u Update)
+	HandleAlbum  func(a Album)
+
+	mu     sync.Mutex
+	albums map[string]*albumBuffer
+}
+
+type albumBuffer struct {
+	album Album
+	timer *time.Timer
+}
+
+func albumMessage(u Update) *Message {
+	if u.Message.MessageId != 0 && u.Message.MediaGroupId != "" {
+		return &u.Message
+	}
+	if u.ChannelPost.MessageId != 0 && u.ChannelPost.MediaGroupId != "" {
+		return &u.ChannelPost
+	}
+	return nil
+}
+
+func (d *AlbumDispatcher) Dispatch(u Update) {
+	m := albumMessage(u)
+	if m == nil {
+		if d.HandleUpdate != nil {
+			d.HandleUpdate(u)
+		}
+		return
+	}
+
+	window := d.Window
+	if window <= 0 {
+		window = AlbumWindowDef
+	}
+	key := F("%d:%s", m.Chat.Id, m.MediaGroupId)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.albums == nil {
+		d.albums = make(map[string]*albumBuffer)
+	}
+
+	if b, ok := d.albums[key]; ok {
+		b.album.Messages = append(b.album.Messages, *m)
+		b.timer.Reset(window)
+		return
+	}
+
+	b := &albumBuffer{
+		album: Album{
+			MediaGroupId: m.MediaGroupId,
+			Chat:         m.Chat,
+			Messages:     []Message{*m},
+		},
+	}
+	b.timer = time.AfterFunc(window, func() { d.flush(key, b) })
+	d.albums[key] = b
+}
+
+// Flush delivers all the buffered albums without waiting for Window to pass.
+func (d *AlbumDispatcher) Flush() {
+	d.mu.Lock()
+	bb := make(map[string]*albumBuffer, len(d.albums))
+	for key, b := range d.albums {
+		bb[key] = b
+	}
+	d.mu.Unlock()
+
+	for key, b := range bb {
+		b.timer.Stop()
+		d.flush(key, b)
+	}
+}
+
+func (d *AlbumDispatcher) flush(key string, b *albumBuffer) {
+	d.mu.Lock()
+	if d.albums[key] != b {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.albums, key)
+	d.mu.Unlock()
+
+	sort.SliceStable(b.album.Messages, func(i, j int) bool {
+		return b.album.Messages[i].MessageId < b.album.Messages[j].MessageId
+	})
+
+	if d.HandleAlbum != nil {
+		d.HandleAlbum(b.album)
+	}
+}
@@ -0,0 +1,55 @@
+package tg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAlbumDispatcher(t *testing.T) {
+
+	albums := make(chan Album, 2)
+	var updates []Update
+
+	d := &AlbumDispatcher{
+		Window: 50 * time.Millisecond,
+
+		HandleUpdate: func(u Update) { updates = append(updates, u) },
+		HandleAlbum:  func(a Album) { albums <- a },
+	}
+
+	chat := Chat{Id: -100}
+	d.Dispatch(Update{UpdateId: 1, Message: Message{MessageId: 12, Chat: chat, MediaGroupId: "g1"}})
+	d.Dispatch(Update{UpdateId: 2, Message: Message{MessageId: 13, Chat: chat, Text: "text"}})
+	d.Dispatch(Update{UpdateId: 3, Message: Message{MessageId: 11, Chat: chat, MediaGroupId: "g1"}})
+	d.Dispatch(Update{UpdateId: 4, ChannelPost: Message{MessageId: 14, Chat: chat, MediaGroupId: "g1"}})
+
+	if len(updates) != 1 || updates[0].UpdateId != 2 {
+		t.Fatalf("updates %#v", updates)
+	}
+
+	select {
+	case a := <-albums:
+		if a.MediaGroupId != "g1" || len(a.Messages) != 3 {
+			t.Fatalf("album %#v", a)
+		}
+		for i, id := range []int64{11, 12, 14} {
+			if a.Messages[i].MessageId != id {
+				t.Errorf("album message #%d id==%d expected %d", i, a.Messages[i].MessageId, id)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("album was not delivered")
+	}
+
+	d.Dispatch(Update{UpdateId: 5, Message: Message{MessageId: 21, Chat: chat, MediaGroupId: "g2"}})
+	d.Flush()
+	select {
+	case a := <-albums:
+		if a.MediaGroupId != "g2" || len(a.Messages) != 1 {
+			t.Fatalf("album %#v", a)
+		}
+	default:
+		t.Fatal("Flush did not deliver album")
+	}
+
+}
@@ -0,0 +1,721 @@
+package tg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// https://id3.org/id3v2.4.0-structure
+// https://id3.org/id3v2.3.0
+// https://www.rfc-editor.org/rfc/rfc7845
+// https://xiph.org/vorbis/doc/Vorbis_I_spec.html
+
+var (
+	AudioCoverMaxSize int64 = 16 << 20
+)
+
+type AudioTags struct {
+	Performer string
+	Title     string
+	Album     string
+	Duration  time.Duration
+
+	Cover         []byte
+	CoverMimeType string
+}
+
+// ReadAudioTags reads tags, duration and cover art of an MP3, M4A, Opus or Vorbis file.
+// The reader is left at an unspecified position.
+func ReadAudioTags(r io.ReadSeeker) (tags AudioTags, err error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return AudioTags{}, fmt.Errorf("Seek %w", err)
+	}
+
+	head := make([]byte, 12)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return AudioTags{}, fmt.Errorf("Read %w", err)
+	}
+	head = head[:n]
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return AudioTags{}, fmt.Errorf("Seek %w", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return readOggTags(r)
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return readM4aTags(r)
+	default:
+		return readMp3Tags(r, start)
+	}
+}
+
+func readMp3Tags(r io.ReadSeeker, start int64) (tags AudioTags, err error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return AudioTags{}, fmt.Errorf("Seek %w", err)
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return AudioTags{}, fmt.Errorf("Seek %w", err)
+	}
+
+	audiostart := start
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err == nil && string(header[0:3]) == "ID3" {
+		size := id3syncsafe(header[6:10])
+		if size > AudioCoverMaxSize+(1<<20) {
+			return AudioTags{}, fmt.Errorf("ID3v2 tag size <%d> is too big", size)
+		}
+		tag := make([]byte, size)
+		if _, err := io.ReadFull(r, tag); err != nil {
+			return AudioTags{}, fmt.Errorf("Read ID3v2 tag %w", err)
+		}
+		readId3v2(header, tag, &tags)
+		audiostart += 10 + size
+		if header[5]&0x10 != 0 {
+			audiostart += 10
+		}
+	}
+
+	audioend := end
+	if end-audiostart >= 128 {
+		if _, err := r.Seek(end-128, io.SeekStart); err != nil {
+			return AudioTags{}, fmt.Errorf("Seek %w", err)
+		}
+		v1 := make([]byte, 128)
+		if _, err := io.ReadFull(r, v1); err == nil && string(v1[0:3]) == "TAG" {
+			audioend -= 128
+			if tags.Title == "" {
+				tags.Title = id3v1string(v1[3:33])
+			}
+			if tags.Performer == "" {
+				tags.Performer = id3v1string(v1[33:63])
+			}
+			if tags.Album == "" {
+				tags.Album = id3v1string(v1[63:93])
+			}
+		}
+	}
+
+	if tags.Duration == 0 {
+		if _, err := r.Seek(audiostart, io.SeekStart); err != nil {
+			return AudioTags{}, fmt.Errorf("Seek %w", err)
+		}
+		frames := make([]byte, 64<<10)
+		n, err := io.ReadFull(r, frames)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return tags, fmt.Errorf("Read %w", err)
+		}
+		tags.Duration = mp3duration(frames[:n], audioend-audiostart)
+	}
+
+	if tags.Duration == 0 && tags.Title == "" && tags.Performer == "" {
+		return tags, fmt.Errorf("no audio tags found")
+	}
+
+	return tags, nil
+}
+
+func id3syncsafe(b []byte) int64 {
+	return int64(b[0]&0x7f)<<21 | int64(b[1]&0x7f)<<14 | int64(b[2]&0x7f)<<7 | int64(b[3]&0x7f)
+}
+
+func id3unsync(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xff, 0x00}, []byte{0xff})
+}
+
+func id3v1string(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(id3latin1(b))
+}
+
+func id3latin1(b []byte) string {
+	rr := make([]rune, len(b))
+	for i, c := range b {
+		rr[i] = rune(c)
+	}
+	return string(rr)
+}
+
+// id3text decodes a string in the given ID3v2 text encoding.
+func id3text(enc byte, b []byte) string {
+	switch enc {
+	case 0:
+		return id3latin1(b)
+	case 1, 2:
+		bigendian := enc == 2
+		if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+			bigendian, b = true, b[2:]
+		} else if len(b) >= 2 && b[0] == 0xff && b[1] == 0xfe {
+			bigendian, b = false, b[2:]
+		}
+		uu := make([]uint16, len(b)/2)
+		for i := range uu {
+			if bigendian {
+				uu[i] = binary.BigEndian.Uint16(b[2*i:])
+			} else {
+				uu[i] = binary.LittleEndian.Uint16(b[2*i:])
+			}
+		}
+		return string(utf16.Decode(uu))
+	default:
+		return string(b)
+	}
+}
+
+// id3split cuts a string terminated according to the text encoding off the beginning of b.
+func id3split(enc byte, b []byte) (s, rest []byte) {
+	if enc == 1 || enc == 2 {
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] == 0 && b[i+1] == 0 {
+				return b[:i], b[i+2:]
+			}
+		}
+		return b, nil
+	}
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		return b[:i], b[i+1:]
+	}
+	return b, nil
+}
+
+func id3textframe(data []byte) string {
+	if len(data) < 1 {
+		return ""
+	}
+	s := id3text(data[0], data[1:])
+	// multiple values are separated by null characters in v2.4
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+func readId3v2(header, tag []byte, tags *AudioTags) {
+	version, flags := header[3], header[5]
+
+	if flags&0x80 != 0 && version < 4 {
+		tag = id3unsync(tag)
+	}
+	if flags&0x40 != 0 && len(tag) >= 4 {
+		if version == 3 {
+			size := int(binary.BigEndian.Uint32(tag[0:4])) + 4
+			if size > len(tag) {
+				return
+			}
+			tag = tag[size:]
+		} else if version == 4 {
+			size := int(id3syncsafe(tag[0:4]))
+			if size > len(tag) {
+				return
+			}
+			tag = tag[size:]
+		}
+	}
+
+	var covertype = -1
+	var length time.Duration
+
+	for {
+		var id string
+		var size int
+		var fflags uint16
+		if version == 2 {
+			if len(tag) < 6 {
+				break
+			}
+			id = string(tag[0:3])
+			size = int(tag[3])<<16 | int(tag[4])<<8 | int(tag[5])
+			tag = tag[6:]
+		} else {
+			if len(tag) < 10 {
+				break
+			}
+			id = string(tag[0:4])
+			if version == 4 {
+				size = int(id3syncsafe(tag[4:8]))
+			} else {
+				size = int(binary.BigEndian.Uint32(tag[4:8]))
+			}
+			fflags = binary.BigEndian.Uint16(tag[8:10])
+			tag = tag[10:]
+		}
+		if id[0] == 0 || size > len(tag) {
+			break
+		}
+		data := tag[:size]
+		tag = tag[size:]
+
+		if version == 3 {
+			if fflags&0x00c0 != 0 {
+				continue
+			}
+			if fflags&0x0020 != 0 && len(data) > 0 {
+				data = data[1:]
+			}
+		} else if version == 4 {
+			if fflags&0x000c != 0 {
+				continue
+			}
+			if fflags&0x0040 != 0 && len(data) > 0 {
+				data = data[1:]
+			}
+			if fflags&0x0001 != 0 && len(data) >= 4 {
+				data = data[4:]
+			}
+			if fflags&0x0002 != 0 {
+				data = id3unsync(data)
+			}
+		}
+
+		switch id {
+		case "TIT2", "TT2":
+			tags.Title = id3textframe(data)
+		case "TPE1", "TP1":
+			tags.Performer = id3textframe(data)
+		case "TPE2", "TP2":
+			if tags.Performer == "" {
+				tags.Performer = id3textframe(data)
+			}
+		case "TALB", "TAL":
+			tags.Album = id3textframe(data)
+		case "TLEN", "TLE":
+			if ms, err := strconv.ParseInt(id3textframe(data), 10, 64); err == nil && ms > 0 {
+				length = time.Duration(ms) * time.Millisecond
+			}
+		case "APIC", "PIC":
+			if len(data) < 2 {
+				continue
+			}
+			enc := data[0]
+			data = data[1:]
+			var mimetype string
+			if id == "PIC" {
+				if len(data) < 3 {
+					continue
+				}
+				switch strings.ToUpper(string(data[0:3])) {
+				case "JPG":
+					mimetype = "image/jpeg"
+				case "PNG":
+					mimetype = "image/png"
+				}
+				data = data[3:]
+			} else {
+				var mime []byte
+				mime, data = id3split(0, data)
+				mimetype = string(mime)
+			}
+			if len(data) < 1 {
+				continue
+			}
+			pictype := int(data[0])
+			_, data = id3split(enc, data[1:])
+			// prefer the front cover over other pictures
+			if len(data) > 0 && (covertype < 0 || covertype != 3 && pictype == 3) {
+				covertype = pictype
+				tags.Cover = append([]byte(nil), data...)
+				tags.CoverMimeType = mimetype
+			}
+		}
+	}
+
+	if length > 0 {
+		tags.Duration = length
+	}
+}
+
+var (
+	mp3bitrates = [2][3][16]int{
+		// MPEG-1 layers I, II, III
+		{
+			{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+			{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+		},
+		// MPEG-2 and MPEG-2.5 layers I, II, III
+		{
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		},
+	}
+	mp3samplerates = [4][3]int{
+		{11025, 12000, 8000},
+		{},
+		{22050, 24000, 16000},
+		{44100, 48000, 32000},
+	}
+)
+
+type mp3frame struct {
+	mpeg1      bool
+	layer      int
+	bitrate    int
+	samplerate int
+	mono       bool
+	size       int
+	samples    int
+}
+
+func mp3header(b []byte) (f mp3frame, ok bool) {
+	if len(b) < 4 || b[0] != 0xff || b[1]&0xe0 != 0xe0 {
+		return f, false
+	}
+	version := int(b[1]>>3) & 3
+	layer := 4 - int(b[1]>>1)&3
+	bitrateidx := int(b[2] >> 4)
+	samplerateidx := int(b[2]>>2) & 3
+	padding := int(b[2]>>1) & 1
+	if version == 1 || layer == 4 || bitrateidx == 0 || bitrateidx == 15 || samplerateidx == 3 {
+		return f, false
+	}
+
+	f.mpeg1 = version == 3
+	f.layer = layer
+	table := 1
+	if f.mpeg1 {
+		table = 0
+	}
+	f.bitrate = mp3bitrates[table][layer-1][bitrateidx] * 1000
+	f.samplerate = mp3samplerates[version][samplerateidx]
+	f.mono = b[3]>>6 == 3
+
+	switch {
+	case layer == 1:
+		f.samples = 384
+		f.size = (12*f.bitrate/f.samplerate + padding) * 4
+	case layer == 3 && !f.mpeg1:
+		f.samples = 576
+		f.size = 72*f.bitrate/f.samplerate + padding
+	default:
+		f.samples = 1152
+		f.size = 144*f.bitrate/f.samplerate + padding
+	}
+
+	return f, true
+}
+
+// mp3duration estimates duration from the Xing or VBRI header of the first frame
+// or from the bitrate of a constant bitrate stream.
+func mp3duration(frames []byte, audiosize int64) time.Duration {
+	var f mp3frame
+	var offset int
+	for ; offset+4 <= len(frames); offset++ {
+		var ok bool
+		if f, ok = mp3header(frames[offset:]); !ok {
+			continue
+		}
+		// next frame header must follow unless the data ends earlier
+		if next := offset + f.size; next+4 > len(frames) {
+			break
+		} else if _, ok := mp3header(frames[next:]); ok {
+			break
+		}
+	}
+	if f.samplerate == 0 || offset+4 > len(frames) {
+		return 0
+	}
+	frame := frames[offset:]
+
+	sideinfo := 32
+	switch {
+	case f.mpeg1 && f.mono:
+		sideinfo = 17
+	case !f.mpeg1 && f.mono:
+		sideinfo = 9
+	case !f.mpeg1:
+		sideinfo = 17
+	}
+
+	var nframes int64
+	if x := 4 + sideinfo; len(frame) >= x+12 && (string(frame[x:x+4]) == "Xing" || string(frame[x:x+4]) == "Info") {
+		if binary.BigEndian.Uint32(frame[x+4:x+8])&1 != 0 {
+			nframes = int64(binary.BigEndian.Uint32(frame[x+8 : x+12]))
+		}
+	} else if x := 4 + 32; len(frame) >= x+18 && string(frame[x:x+4]) == "VBRI" {
+		nframes = int64(binary.BigEndian.Uint32(frame[x+14 : x+18]))
+	}
+
+	if nframes > 0 {
+		return time.Duration(float64(nframes) * float64(f.samples) / float64(f.samplerate) * float64(time.Second))
+	}
+
+	audiosize -= int64(offset)
+	if audiosize <= 0 {
+		return 0
+	}
+	return time.Duration(float64(audiosize) * 8 / float64(f.bitrate) * float64(time.Second))
+}
+
+func readM4aTags(r io.ReadSeeker) (tags AudioTags, err error) {
+	moov, _, err := mp4readmoov(r)
+	if err != nil {
+		return AudioTags{}, err
+	}
+
+	tags.Duration = mp4duration(mp4child(moov, "mvhd"))
+
+	meta := mp4child(moov, "udta", "meta")
+	// meta is a full box in mp4 files and a plain box in quicktime ones
+	if len(meta) >= 4 && bytes.Equal(meta[0:4], []byte{0, 0, 0, 0}) {
+		meta = meta[4:]
+	}
+	for _, item := range mp4boxes(mp4child(meta, "ilst")) {
+		data := mp4child(item.data, "data")
+		if len(data) < 8 {
+			continue
+		}
+		datatype, value := binary.BigEndian.Uint32(data[0:4])&0xffffff, data[8:]
+		switch item.typ {
+		case "\xa9nam":
+			tags.Title = string(value)
+		case "\xa9ART":
+			tags.Performer = string(value)
+		case "aART":
+			if tags.Performer == "" {
+				tags.Performer = string(value)
+			}
+		case "\xa9alb":
+			tags.Album = string(value)
+		case "covr":
+			tags.Cover = append([]byte(nil), value...)
+			switch datatype {
+			case 13:
+				tags.CoverMimeType = "image/jpeg"
+			case 14:
+				tags.CoverMimeType = "image/png"
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+// oggreader assembles packets from the pages of the first logical bitstream.
+type oggreader struct {
+	r       io.Reader
+	serial  uint32
+	started bool
+	lacing  []byte
+}
+
+func (o *oggreader) packet(maxsize int64) (packet []byte, err error) {
+	for {
+		for len(o.lacing) > 0 {
+			n := int(o.lacing[0])
+			o.lacing = o.lacing[1:]
+			if int64(len(packet)+n) > maxsize {
+				return nil, fmt.Errorf("ogg packet is too big")
+			}
+			b := make([]byte, n)
+			if _, err := io.ReadFull(o.r, b); err != nil {
+				return nil, fmt.Errorf("Read %w", err)
+			}
+			packet = append(packet, b...)
+			if n < 255 {
+				return packet, nil
+			}
+		}
+
+		header := make([]byte, 27)
+		if _, err := io.ReadFull(o.r, header); err != nil {
+			return nil, fmt.Errorf("Read %w", err)
+		}
+		if string(header[0:4]) != "OggS" {
+			return nil, fmt.Errorf("ogg page not found")
+		}
+		serial := binary.LittleEndian.Uint32(header[14:18])
+		lacing := make([]byte, header[26])
+		if _, err := io.ReadFull(o.r, lacing); err != nil {
+			return nil, fmt.Errorf("Read %w", err)
+		}
+		if o.started && serial != o.serial {
+			var size int64
+			for _, n := range lacing {
+				size += int64(n)
+			}
+			if _, err := io.CopyN(io.Discard, o.r, size); err != nil {
+				return nil, fmt.Errorf("Read %w", err)
+			}
+			continue
+		}
+		o.serial, o.started = serial, true
+		o.lacing = lacing
+	}
+}
+
+func readOggTags(r io.ReadSeeker) (tags AudioTags, err error) {
+	o := &oggreader{r: r}
+
+	ident, err := o.packet(1 << 10)
+	if err != nil {
+		return AudioTags{}, err
+	}
+
+	var samplerate, preskip int64
+	var comments []byte
+	switch {
+	case bytes.HasPrefix(ident, []byte("OpusHead")) && len(ident) >= 12:
+		samplerate, preskip = 48000, int64(binary.LittleEndian.Uint16(ident[10:12]))
+		packet, err := o.packet(AudioCoverMaxSize * 2)
+		if err != nil {
+			return AudioTags{}, err
+		}
+		if !bytes.HasPrefix(packet, []byte("OpusTags")) {
+			return AudioTags{}, fmt.Errorf("OpusTags packet not found")
+		}
+		comments = packet[8:]
+	case bytes.HasPrefix(ident, []byte("\x01vorbis")) && len(ident) >= 16:
+		samplerate = int64(binary.LittleEndian.Uint32(ident[12:16]))
+		packet, err := o.packet(AudioCoverMaxSize * 2)
+		if err != nil {
+			return AudioTags{}, err
+		}
+		if !bytes.HasPrefix(packet, []byte("\x03vorbis")) {
+			return AudioTags{}, fmt.Errorf("vorbis comment packet not found")
+		}
+		comments = packet[7:]
+	default:
+		return AudioTags{}, fmt.Errorf("unsupported ogg stream")
+	}
+
+	readVorbisComments(comments, &tags)
+
+	if granule, err := oggLastGranule(r, o.serial); err == nil && samplerate > 0 && granule > preskip {
+		tags.Duration = time.Duration(float64(granule-preskip) / float64(samplerate) * float64(time.Second))
+	}
+
+	return tags, nil
+}
+
+func oggLastGranule(r io.ReadSeeker, serial uint32) (granule int64, err error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	size := int64(64 << 10)
+	if size > end {
+		size = end
+	}
+	if _, err := r.Seek(end-size, io.SeekStart); err != nil {
+		return 0, err
+	}
+	tail := make([]byte, size)
+	if _, err := io.ReadFull(r, tail); err != nil {
+		return 0, err
+	}
+	for i := len(tail) - 27; i >= 0; i-- {
+		if string(tail[i:i+4]) != "OggS" || binary.LittleEndian.Uint32(tail[i+14:i+18]) != serial {
+			continue
+		}
+		granule = int64(binary.LittleEndian.Uint64(tail[i+6 : i+14]))
+		if granule >= 0 {
+			return granule, nil
+		}
+	}
+	return 0, fmt.Errorf("last ogg page not found")
+}
+
+func readVorbisComments(b []byte, tags *AudioTags) {
+	next := func() ([]byte, bool) {
+		if len(b) < 4 {
+			return nil, false
+		}
+		n := binary.LittleEndian.Uint32(b[0:4])
+		if uint64(n) > uint64(len(b)-4) {
+			return nil, false
+		}
+		s := b[4 : 4+n]
+		b = b[4+n:]
+		return s, true
+	}
+
+	if _, ok := next(); !ok {
+		return
+	}
+	if len(b) < 4 {
+		return
+	}
+	count := binary.LittleEndian.Uint32(b[0:4])
+	b = b[4:]
+
+	for i := uint32(0); i < count; i++ {
+		comment, ok := next()
+		if !ok {
+			return
+		}
+		key, value, ok := strings.Cut(string(comment), "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			tags.Title = value
+		case "ARTIST":
+			tags.Performer = value
+		case "ALBUMARTIST":
+			if tags.Performer == "" {
+				tags.Performer = value
+			}
+		case "ALBUM":
+			tags.Album = value
+		case "METADATA_BLOCK_PICTURE":
+			if tags.Cover == nil {
+				tags.Cover, tags.CoverMimeType = flacPicture(value)
+			}
+		}
+	}
+}
+
+// https://xiph.org/flac/format.html#metadata_block_picture
+func flacPicture(b64 string) (picture []byte, mimetype string) {
+	b, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, ""
+	}
+	next := func() ([]byte, bool) {
+		if len(b) < 4 {
+			return nil, false
+		}
+		n := binary.BigEndian.Uint32(b[0:4])
+		if uint64(n) > uint64(len(b)-4) {
+			return nil, false
+		}
+		s := b[4 : 4+n]
+		b = b[4+n:]
+		return s, true
+	}
+
+	if len(b) < 4 {
+		return nil, ""
+	}
+	b = b[4:]
+	mime, ok := next()
+	if !ok {
+		return nil, ""
+	}
+	if _, ok := next(); !ok {
+		return nil, ""
+	}
+	if len(b) < 16 {
+		return nil, ""
+	}
+	b = b[16:]
+	data, ok := next()
+	if !ok {
+		return nil, ""
+	}
+	return data, string(mime)
+}
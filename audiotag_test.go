@@ -0,0 +1,210 @@
+package tg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"mime/multipart"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testId3Frame(id string, data []byte) []byte {
+	b := make([]byte, 10, 10+len(data))
+	copy(b[0:4], id)
+	binary.BigEndian.PutUint32(b[4:8], uint32(len(data)))
+	return append(b, data...)
+}
+
+func testMp3Frames(n int) []byte {
+	// MPEG-1 layer III 128 kbps 44100 Hz stereo, 417 bytes per frame
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xff, 0xfb, 0x90, 0x00})
+	return bytes.Repeat(frame, n)
+}
+
+func TestReadAudioTagsMp3(t *testing.T) {
+
+	var frames []byte
+	frames = append(frames, testId3Frame("TIT2", append([]byte{3}, "Title Ω"...))...)
+	// UTF-16 with byte order mark
+	frames = append(frames, testId3Frame("TPE1", []byte{1, 0xff, 0xfe, 'A', 0, 'r', 0, 't', 0})...)
+	frames = append(frames, testId3Frame("APIC", append([]byte{0}, "image/jpeg\x00\x03cover\x00JPEGDATA"...))...)
+
+	header := []byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 0}
+	size := len(frames)
+	header[6], header[7], header[8], header[9] = byte(size>>21&0x7f), byte(size>>14&0x7f), byte(size>>7&0x7f), byte(size&0x7f)
+
+	nframes := 1000
+	mp3 := append(append(header, frames...), testMp3Frames(nframes)...)
+
+	tags, err := ReadAudioTags(bytes.NewReader(mp3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags.Title != "Title Ω" || tags.Performer != "Art" {
+		t.Errorf("tags %#v", tags)
+	}
+	if string(tags.Cover) != "JPEGDATA" || tags.CoverMimeType != "image/jpeg" {
+		t.Errorf("cover %q %q", tags.Cover, tags.CoverMimeType)
+	}
+	expected := time.Duration(float64(nframes*417*8) / 128000 * float64(time.Second))
+	if d := tags.Duration - expected; d < -time.Millisecond || d > time.Millisecond {
+		t.Errorf("duration %v expected %v", tags.Duration, expected)
+	}
+
+	v1 := make([]byte, 128)
+	copy(v1, "TAG")
+	copy(v1[3:], "v1 title")
+	copy(v1[33:], "v1 artist")
+	tags, err = ReadAudioTags(bytes.NewReader(append(testMp3Frames(10), v1...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags.Title != "v1 title" || tags.Performer != "v1 artist" || tags.Duration == 0 {
+		t.Errorf("tags %#v", tags)
+	}
+
+}
+
+func testOggPage(serial uint32, granule int64, packets ...[]byte) []byte {
+	var lacing, data []byte
+	for _, p := range packets {
+		n := len(p)
+		for ; n >= 255; n -= 255 {
+			lacing = append(lacing, 255)
+		}
+		lacing = append(lacing, byte(n))
+		data = append(data, p...)
+	}
+	header := make([]byte, 27)
+	copy(header, "OggS")
+	binary.LittleEndian.PutUint64(header[6:14], uint64(granule))
+	binary.LittleEndian.PutUint32(header[14:18], serial)
+	header[26] = byte(len(lacing))
+	return append(append(header, lacing...), data...)
+}
+
+func TestReadAudioTagsOpus(t *testing.T) {
+
+	head := append([]byte("OpusHead\x01\x02"), 0x38, 0x01, 0x80, 0xbb, 0, 0, 0, 0, 0)
+
+	picture := []byte{0, 0, 0, 3, 0, 0, 0, 9}
+	picture = append(picture, "image/png"...)
+	picture = append(picture, make([]byte, 4+16)...)
+	picture = append(picture, 0, 0, 0, 3, 'P', 'N', 'G')
+
+	var comments []byte
+	add := func(s string) {
+		comments = binary.LittleEndian.AppendUint32(comments, uint32(len(s)))
+		comments = append(comments, s...)
+	}
+	add("vendor")
+	comments = binary.LittleEndian.AppendUint32(comments, 3)
+	add("TITLE=Opus title")
+	add("ARTIST=Opus artist")
+	add("METADATA_BLOCK_PICTURE=" + base64.StdEncoding.EncodeToString(picture))
+
+	ogg := testOggPage(7, 0, head)
+	ogg = append(ogg, testOggPage(7, 0, append([]byte("OpusTags"), comments...))...)
+	ogg = append(ogg, testOggPage(7, 312+48000*3, make([]byte, 100))...)
+
+	tags, err := ReadAudioTags(bytes.NewReader(ogg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags.Title != "Opus title" || tags.Performer != "Opus artist" || tags.Duration != 3*time.Second {
+		t.Errorf("tags %#v", tags)
+	}
+	if string(tags.Cover) != "PNG" || tags.CoverMimeType != "image/png" {
+		t.Errorf("cover %q %q", tags.Cover, tags.CoverMimeType)
+	}
+
+}
+
+func TestReadAudioTagsM4a(t *testing.T) {
+
+	item := func(typ string, datatype uint32, value string) []byte {
+		data := binary.BigEndian.AppendUint32(nil, datatype)
+		data = append(data, 0, 0, 0, 0)
+		return testMp4Box(typ, testMp4Box("data", data, []byte(value)))
+	}
+
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:16], 44100)
+	binary.BigEndian.PutUint32(mvhd[16:20], 44100*90)
+
+	moov := testMp4Box("moov",
+		testMp4Box("mvhd", mvhd),
+		testMp4Box("udta", testMp4Box("meta", make([]byte, 4), testMp4Box("ilst",
+			item("\xa9nam", 1, "M4A title"),
+			item("\xa9ART", 1, "M4A artist"),
+			item("covr", 13, "JPEG"),
+		))),
+	)
+	m4a := append(testMp4Box("ftyp", []byte("M4A \x00\x00\x00\x00")), moov...)
+
+	tags, err := ReadAudioTags(bytes.NewReader(m4a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags.Title != "M4A title" || tags.Performer != "M4A artist" || tags.Duration != 90*time.Second {
+		t.Errorf("tags %#v", tags)
+	}
+	if string(tags.Cover) != "JPEG" || tags.CoverMimeType != "image/jpeg" {
+		t.Errorf("cover %q %q", tags.Cover, tags.CoverMimeType)
+	}
+
+}
+
+func TestSendAudioFileReadTags(t *testing.T) {
+
+	var form *multipart.Form
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+		}
+		form = r.MultipartForm
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"audio":{"file_id":"audio"}}}`))
+	})
+
+	var frames []byte
+	frames = append(frames, testId3Frame("TIT2", append([]byte{3}, "Title"...))...)
+	frames = append(frames, testId3Frame("APIC", append(append([]byte{0}, "image/png\x00\x03\x00"...), testPng(t, 640, 400)...))...)
+	header := []byte{'I', 'D', '3', 3, 0, 0, 0, 0, 0, 0}
+	size := len(frames)
+	header[6], header[7], header[8], header[9] = byte(size>>21&0x7f), byte(size>>14&0x7f), byte(size>>7&0x7f), byte(size&0x7f)
+	mp3 := append(append(header, frames...), testMp3Frames(100)...)
+
+	if _, err := SendAudioFile(SendAudioFileRequest{ChatId: "1", Audio: bytes.NewReader(mp3)}); err != nil {
+		t.Fatal(err)
+	}
+	if form.Value["title"][0] != "" || form.File["thumbnail"] != nil {
+		t.Errorf("tags read without ReadTags: title %q thumbnail %v", form.Value["title"], form.File["thumbnail"])
+	}
+
+	if _, err := SendAudioFile(SendAudioFileRequest{ChatId: "1", Audio: bytes.NewReader(mp3), ReadTags: true}); err != nil {
+		t.Fatal(err)
+	}
+	if form.Value["title"][0] != "Title" {
+		t.Errorf("title %q", form.Value["title"])
+	}
+	if f := form.File["thumbnail"]; len(f) != 1 {
+		t.Fatalf("thumbnail %v", f)
+	}
+	thumb, err := form.File["thumbnail"][0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer thumb.Close()
+	cfg, format, err := image.DecodeConfig(thumb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "jpeg" || cfg.Width != ThumbnailMaxSide || cfg.Height != 200 {
+		t.Errorf("thumbnail %s %dx%d", format, cfg.Width, cfg.Height)
+	}
+
+}
@@ -0,0 +1,174 @@
+package tg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	// when set, SendPhotoFile, SendAudioFile and SendVideoFile send previously uploaded content by file_id;
+	// content of readers that are not io.ReadSeeker is read into memory to be hashed
+	FileIdCache UploadCache
+)
+
+// UploadCache maps SHA-256 of uploaded content to the file_id telegram assigned to it.
+type UploadCache interface {
+	Get(key string) (fileid string, ok bool)
+	Put(key string, fileid string) error
+}
+
+type MemoryUploadCache struct {
+	mu      sync.Mutex
+	fileids map[string]string
+}
+
+func NewMemoryUploadCache() *MemoryUploadCache {
+	return &MemoryUploadCache{fileids: make(map[string]string)}
+}
+
+func (c *MemoryUploadCache) Get(key string) (fileid string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fileid, ok = c.fileids[key]
+	return fileid, ok
+}
+
+func (c *MemoryUploadCache) Put(key string, fileid string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fileids[key] = fileid
+	return nil
+}
+
+// FileUploadCache keeps the cache in a json file rewritten on every Put.
+type FileUploadCache struct {
+	Path string
+
+	mu      sync.Mutex
+	fileids map[string]string
+}
+
+func NewFileUploadCache(path string) (*FileUploadCache, error) {
+	c := &FileUploadCache{Path: path, fileids: make(map[string]string)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("ReadFile %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return c, nil
+	}
+	if err := json.Unmarshal(data, &c.fileids); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal %s %w", path, err)
+	}
+
+	return c, nil
+}
+
+func (c *FileUploadCache) Get(key string) (fileid string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fileid, ok = c.fileids[key]
+	return fileid, ok
+}
+
+func (c *FileUploadCache) Put(key string, fileid string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fileids[key] == fileid {
+		return nil
+	}
+	c.fileids[key] = fileid
+
+	data, err := json.MarshalIndent(c.fileids, "", "\t")
+	if err != nil {
+		return fmt.Errorf("json.Marshal %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.Path), filepath.Base(c.Path)+".*")
+	if err != nil {
+		return fmt.Errorf("CreateTemp %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Write %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Close %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.Path); err != nil {
+		return fmt.Errorf("Rename %w", err)
+	}
+
+	return nil
+}
+
+// uploadCacheKey hashes the content of r and returns a reader of the same content.
+// Seekable readers are rewound, others are read into memory.
+func uploadCacheKey(kind string, r io.Reader) (key string, rr io.Reader, err error) {
+	h := sha256.New()
+	if rs, ok := r.(io.ReadSeeker); ok {
+		pos, err := rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return "", nil, fmt.Errorf("Seek %w", err)
+		}
+		if _, err := io.Copy(h, rs); err != nil {
+			return "", nil, fmt.Errorf("Copy %w", err)
+		}
+		if _, err := rs.Seek(pos, io.SeekStart); err != nil {
+			return "", nil, fmt.Errorf("Seek %w", err)
+		}
+		rr = rs
+	} else {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return "", nil, fmt.Errorf("ReadAll %w", err)
+		}
+		h.Write(data)
+		rr = bytes.NewReader(data)
+	}
+	return kind + ":" + hex.EncodeToString(h.Sum(nil)), rr, nil
+}
+
+func uploadCachePut(key, fileid string) {
+	if FileIdCache == nil || key == "" || fileid == "" {
+		return
+	}
+	if err := FileIdCache.Put(key, fileid); err != nil {
+		perr(F("ERROR FileIdCache.Put %v", err))
+	}
+}
+
+// uploadCacheGet returns the cache key of the content of r, the file_id cached for it if any
+// and a reader of the same content to upload on a miss.
+func uploadCacheGet(kind string, r io.Reader) (key, fileid string, rr io.Reader, err error) {
+	if FileIdCache == nil {
+		return "", "", r, nil
+	}
+	key, rr, err = uploadCacheKey(kind, r)
+	if err != nil {
+		return "", "", nil, err
+	}
+	fileid, _ = FileIdCache.Get(key)
+	return key, fileid, rr, nil
+}
+
+// fileCaptionEntities returns the caption entities for the file_id resend of an upload
+// keeping a caption uploaded without ParseMode and entities plain instead of getting the default ParseMode.
+func fileCaptionEntities(parseMode string, entities []MessageEntity) []MessageEntity {
+	if parseMode == "" && entities == nil {
+		return []MessageEntity{}
+	}
+	return entities
+}
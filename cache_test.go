@@ -0,0 +1,134 @@
+package tg
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileUploadCache(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "fileids.json")
+
+	c, err := NewFileUploadCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Put("photo:abc", "fileid1"); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = NewFileUploadCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileid, ok := c.Get("photo:abc"); !ok || fileid != "fileid1" {
+		t.Errorf("Get fileid %q ok %v", fileid, ok)
+	}
+	if _, ok := c.Get("photo:def"); ok {
+		t.Errorf("Get unknown key ok")
+	}
+
+}
+
+func TestSendPhotoFileCache(t *testing.T) {
+
+	var uploads, fileids int
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			uploads++
+		} else {
+			fileids++
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"photo":[{"file_id":"small"},{"file_id":"large"}]}}`))
+	})
+
+	defer func(cache UploadCache) { FileIdCache = cache }(FileIdCache)
+	FileIdCache = NewMemoryUploadCache()
+
+	photo := testPng(t, 20, 10)
+	for i := 0; i < 3; i++ {
+		if _, err := SendPhotoFile(SendPhotoFileRequest{ChatId: "1", Photo: bytes.NewReader(photo)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if uploads != 1 || fileids != 2 {
+		t.Errorf("uploads <%d> file_id sends <%d>", uploads, fileids)
+	}
+
+}
+
+func TestSendAudioFileCache(t *testing.T) {
+
+	var req map[string]interface{}
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		req = nil
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"audio":{"file_id":"audio"}}}`))
+	})
+
+	defer func(cache UploadCache) { FileIdCache = cache }(FileIdCache)
+	FileIdCache = NewMemoryUploadCache()
+
+	for _, tc := range []struct {
+		caption, parseMode string
+	}{
+		{"1.5 *x*", ""},
+		{"1\\.5 *x*", ParseModeMarkdownV2},
+	} {
+		for i := 0; i < 2; i++ {
+			audio := SendAudioFileRequest{ChatId: "1", Caption: tc.caption, ParseMode: tc.parseMode, Performer: "Art", Title: "Song", Duration: 3 * time.Minute, Audio: strings.NewReader("mp3")}
+			if _, err := SendAudioFile(audio); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if req == nil {
+			t.Fatalf("cached file_id not sent")
+		}
+		if req["audio"] != "audio" || req["caption"] != tc.caption || req["performer"] != "Art" || req["title"] != "Song" || req["duration"] != float64(180) {
+			t.Errorf("request %v", req)
+		}
+		if parseMode, _ := req["parse_mode"].(string); parseMode != tc.parseMode {
+			t.Errorf("parse_mode %q expected %q", parseMode, tc.parseMode)
+		}
+	}
+
+}
+
+func TestSendVideoFileCacheProbe(t *testing.T) {
+
+	var req map[string]interface{}
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		req = nil
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"video":{"file_id":"video"}}}`))
+	})
+
+	defer func(cache UploadCache) { FileIdCache = cache }(FileIdCache)
+	FileIdCache = NewMemoryUploadCache()
+
+	for i := 0; i < 2; i++ {
+		if _, err := SendVideoFile(SendVideoFileRequest{ChatId: "1", Video: bytes.NewReader(testMp4(false)), Probe: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if req == nil {
+		t.Fatalf("cached file_id not sent")
+	}
+	if req["video"] != "video" || req["width"] != float64(640) || req["height"] != float64(360) || req["duration"] != float64(5) || req["supports_streaming"] != true {
+		t.Errorf("request %v", req)
+	}
+
+}
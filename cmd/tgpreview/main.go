@@ -0,0 +1,62 @@
+// tgpreview renders MarkdownV2 messages as an html page approximating the telegram look.
+//
+//	tgpreview [-o page.html] [-title title] [message.md ...]
+//
+// Each file is one message, without files one message is read from stdin.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shoce/tg"
+)
+
+func main() {
+	output := flag.String("o", "", "write the page to the file instead of stdout")
+	title := flag.String("title", "tgpreview", "page title")
+	flag.Parse()
+
+	var messages []tg.TextChunk
+	if flag.NArg() == 0 {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fatal("ReadAll stdin %v", err)
+		}
+		text, entities, err := tg.ParseMarkdown(string(b))
+		if err != nil {
+			fatal("stdin %v", err)
+		}
+		messages = append(messages, tg.TextChunk{Text: text, Entities: entities})
+	}
+	for _, path := range flag.Args() {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			fatal("ReadFile %v", err)
+		}
+		text, entities, err := tg.ParseMarkdown(string(b))
+		if err != nil {
+			fatal("%s %v", path, err)
+		}
+		messages = append(messages, tg.TextChunk{Text: text, Entities: entities})
+	}
+
+	page := tg.PreviewEntitiesPage(*title, messages...)
+
+	if *output == "" {
+		if _, err := os.Stdout.WriteString(page); err != nil {
+			fatal("Write %v", err)
+		}
+		return
+	}
+	if err := os.WriteFile(*output, []byte(page), 0644); err != nil {
+		fatal("WriteFile %v", err)
+	}
+}
+
+func fatal(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, "ERROR "+format+"\n", a...)
+	os.Exit(1)
+}
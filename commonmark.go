@@ -0,0 +1,550 @@
+package tg
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// https://spec.commonmark.org/
+// https://github.github.com/gfm/#tables-extension-
+
+var (
+	cmFenceRe     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s]*)")
+	cmHeadingRe   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	cmSetextRe    = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	cmBreakRe     = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	cmQuoteRe     = regexp.MustCompile(`^ {0,3}> ?`)
+	cmListRe      = regexp.MustCompile(`^( {0,3})([-+*]|\d{1,9}[.)])([ \t]+|$)`)
+	cmTaskRe      = regexp.MustCompile(`^\[([ xX])\][ \t]+`)
+	cmTableSepRe  = regexp.MustCompile(`^ {0,3}\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	cmAutolinkRe  = regexp.MustCompile(`^<([a-zA-Z][a-zA-Z0-9+.-]{1,31}:[^\s<>]*|[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9.-]+)>`)
+	cmLinkDestRe  = regexp.MustCompile(`^\(\s*(<[^<>\n]*>|[^\s()]*(?:\([^\s()]*\)[^\s()]*)*)(?:\s+("[^"]*"|'[^']*'|\([^()]*\)))?\s*\)`)
+	cmListBullets = []string{"•", "◦", "▪"}
+)
+
+// CommonMark converts standard markdown to MarkdownV2.
+// Headings become bold, list items get bullets or numbers,
+// fenced and indented code becomes pre blocks, tables are aligned in a pre block
+// and nested blockquotes are flattened as telegram does not nest them.
+func CommonMark(s string) Markdown {
+	s = strings.ReplaceAll(s, "\r\n", NL)
+	s = strings.ReplaceAll(s, "\t", "    ")
+	return cmBlocks(strings.Split(s, NL), 0, false)
+}
+
+// CommonMarkEntities converts standard markdown to text with entities.
+func CommonMarkEntities(s string) (text string, entities []MessageEntity) {
+	m := CommonMark(s)
+	text, entities, err := ParseMarkdown(m.s)
+	if err != nil {
+		perr(F("ERROR CommonMarkEntities ParseMarkdown %v", err))
+		return PlainText(m.s, ParseModeMarkdownV2), nil
+	}
+	return text, entities
+}
+
+func cmBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// cmBlockStart reports if the line interrupts a paragraph.
+func cmBlockStart(line string) bool {
+	if cmFenceRe.MatchString(line) || cmHeadingRe.MatchString(line) || cmBreakRe.MatchString(line) || cmQuoteRe.MatchString(line) {
+		return true
+	}
+	if m := cmListRe.FindStringSubmatch(line); m != nil && m[3] != "" {
+		// only lists starting with 1 interrupt a paragraph
+		return !unicode.IsDigit(rune(m[2][0])) || strings.TrimLeft(m[2], "0") == "1." || strings.TrimLeft(m[2], "0") == "1)"
+	}
+	return false
+}
+
+func cmIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// cmBlocks converts lines of blocks, depth is the list nesting level.
+func cmBlocks(lines []string, depth int, inquote bool) Markdown {
+	var blocks []Markdown
+	sep := RawMarkdown(NL + NL)
+	if depth > 0 {
+		sep = RawMarkdown(NL)
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+
+		if cmBlank(line) {
+			i++
+			continue
+		}
+
+		if m := cmFenceRe.FindStringSubmatch(line); m != nil {
+			fence, indent := m[1], cmIndent(line)
+			var code []string
+			for i++; i < len(lines); i++ {
+				if l := strings.TrimSpace(lines[i]); strings.HasPrefix(l, fence) && strings.Trim(l, fence[:1]) == "" && cmIndent(lines[i]) < 4 {
+					i++
+					break
+				}
+				code = append(code, strings.TrimPrefix(lines[i], strings.Repeat(" ", min(indent, cmIndent(lines[i])))))
+			}
+			blocks = append(blocks, RawMarkdown(PreLang(m[2], strings.Join(code, NL))))
+			continue
+		}
+
+		if cmIndent(line) >= 4 {
+			var code []string
+			for ; i < len(lines) && (cmBlank(lines[i]) || cmIndent(lines[i]) >= 4); i++ {
+				code = append(code, strings.TrimPrefix(lines[i], "    "))
+			}
+			for len(code) > 0 && cmBlank(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+			blocks = append(blocks, RawMarkdown(Pre(strings.Join(code, NL))))
+			continue
+		}
+
+		if m := cmHeadingRe.FindStringSubmatch(line); m != nil {
+			blocks = append(blocks, cmHeading(len(m[1]), m[2]))
+			i++
+			continue
+		}
+
+		if cmBreakRe.MatchString(line) {
+			blocks = append(blocks, MarkdownText(strings.Repeat("—", 10)))
+			i++
+			continue
+		}
+
+		if cmQuoteRe.MatchString(line) {
+			var quote []string
+			for ; i < len(lines) && !cmBlank(lines[i]); i++ {
+				if loc := cmQuoteRe.FindStringIndex(lines[i]); loc != nil {
+					quote = append(quote, lines[i][loc[1]:])
+				} else if len(quote) > 0 && !cmBlockStart(lines[i]) {
+					// lazy continuation line
+					quote = append(quote, lines[i])
+				} else {
+					break
+				}
+			}
+			m := cmBlocks(quote, 0, true)
+			if !inquote {
+				m = m.Quote()
+			}
+			blocks = append(blocks, m)
+			continue
+		}
+
+		if i+1 < len(lines) && strings.Contains(line, "|") && cmTableSepRe.MatchString(lines[i+1]) {
+			header, aligns := cmTableRow(line), cmTableAligns(lines[i+1])
+			if len(header) == len(aligns) {
+				rows := [][]string{header}
+				for i += 2; i < len(lines) && !cmBlank(lines[i]) && strings.Contains(lines[i], "|"); i++ {
+					rows = append(rows, cmTableRow(lines[i]))
+				}
+				blocks = append(blocks, cmTable(rows, aligns))
+				continue
+			}
+		}
+
+		if cmListRe.MatchString(line) {
+			var m Markdown
+			m, i = cmList(lines, i, depth, inquote)
+			blocks = append(blocks, m)
+			continue
+		}
+
+		var para []string
+		for ; i < len(lines) && !cmBlank(lines[i]); i++ {
+			if len(para) > 0 && cmSetextRe.MatchString(lines[i]) {
+				level := 2
+				if strings.TrimSpace(lines[i])[0] == '=' {
+					level = 1
+				}
+				blocks = append(blocks, cmHeading(level, strings.Join(para, NL)))
+				para = nil
+				i++
+				break
+			}
+			if len(para) > 0 && cmBlockStart(lines[i]) {
+				break
+			}
+			para = append(para, strings.TrimLeft(lines[i], " "))
+		}
+		if len(para) > 0 {
+			blocks = append(blocks, cmParagraph(para))
+		}
+	}
+
+	var mm []Markdown
+	for i, b := range blocks {
+		if i > 0 {
+			mm = append(mm, sep)
+		}
+		mm = append(mm, RawMarkdown(strings.TrimSuffix(b.s, NL)))
+	}
+	return JoinMarkdown(mm...)
+}
+
+// cmList converts the list starting at lines[i] and returns the index of the line after it.
+func cmList(lines []string, i int, depth int, inquote bool) (Markdown, int) {
+	first := cmListRe.FindStringSubmatch(lines[i])
+	ordered := unicode.IsDigit(rune(first[2][0]))
+	delim := first[2][len(first[2])-1:]
+	num, _ := strconv.Atoi(strings.TrimRight(first[2], ".)"))
+
+	var items []Markdown
+	for i < len(lines) {
+		m := cmListRe.FindStringSubmatch(lines[i])
+		if m == nil || unicode.IsDigit(rune(m[2][0])) != ordered || m[2][len(m[2])-1:] != delim || cmIndent(lines[i]) > 3 {
+			break
+		}
+
+		width := len(m[0])
+		if m[3] == "" || len(m[3]) > 4 {
+			// content starts on the next line or is indented code
+			width = len(m[1]) + len(m[2]) + 1
+		}
+		item := []string{strings.TrimPrefix(lines[i][min(width, len(lines[i])):], " ")}
+		for i++; i < len(lines); i++ {
+			if cmBlank(lines[i]) {
+				item = append(item, "")
+				continue
+			}
+			if cmIndent(lines[i]) >= width {
+				item = append(item, lines[i][width:])
+				continue
+			}
+			if !cmBlank(item[len(item)-1]) && !cmBlockStart(lines[i]) && !cmListRe.MatchString(lines[i]) {
+				// lazy continuation line
+				item = append(item, lines[i])
+				continue
+			}
+			break
+		}
+
+		var marker Markdown
+		if ordered {
+			marker = MarkdownTextf("%d.", num)
+			num++
+		} else {
+			marker = RawMarkdown(cmListBullets[min(depth, len(cmListBullets)-1)])
+		}
+		if t := cmTaskRe.FindStringSubmatch(item[0]); t != nil {
+			item[0] = item[0][len(t[0]):]
+			if t[1] == " " {
+				marker = RawMarkdown(marker.s + " ☐")
+			} else {
+				marker = RawMarkdown(marker.s + " ☑")
+			}
+		}
+
+		content := cmBlocks(item, depth+1, inquote)
+		indent := RawMarkdown(strings.Repeat("  ", depth))
+		if strings.HasPrefix(content.s, ">") || strings.HasPrefix(content.s, "```") {
+			items = append(items, JoinMarkdown(indent, marker, RawMarkdown(NL), content))
+		} else {
+			items = append(items, JoinMarkdown(indent, marker, RawMarkdown(SP), content))
+		}
+
+		// a blank line ends the list unless the next item follows
+		j := i
+		for j < len(lines) && cmBlank(lines[j]) {
+			j++
+		}
+		if j > i && (j == len(lines) || !cmListRe.MatchString(lines[j])) {
+			break
+		}
+		i = j
+	}
+
+	var mm []Markdown
+	for k, item := range items {
+		if k > 0 {
+			mm = append(mm, RawMarkdown(NL))
+		}
+		mm = append(mm, item)
+	}
+	return JoinMarkdown(mm...), i
+}
+
+func cmHeading(level int, text string) Markdown {
+	m := cmInline(strings.TrimSpace(text))
+	switch level {
+	case 1:
+		return m.Bold().Underline()
+	case 2:
+		return m.Bold()
+	default:
+		return m.Italic().Bold()
+	}
+}
+
+func cmParagraph(lines []string) Markdown {
+	var b strings.Builder
+	for i, line := range lines {
+		if i == len(lines)-1 {
+			b.WriteString(strings.TrimRight(line, " "))
+		} else if strings.HasSuffix(line, "  ") {
+			b.WriteString(strings.TrimRight(line, " ") + NL)
+		} else if strings.HasSuffix(line, "\\") {
+			b.WriteString(strings.TrimSuffix(line, "\\") + NL)
+		} else {
+			b.WriteString(strings.TrimRight(line, " ") + SP)
+		}
+	}
+	return cmInline(b.String())
+}
+
+func cmTableRow(line string) (cells []string) {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, "\\|") {
+		line = line[:len(line)-1]
+	}
+	var cell strings.Builder
+	var code bool
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+			continue
+		case line[i] == '`':
+			code = !code
+		case line[i] == '|' && !code:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+			continue
+		}
+		cell.WriteByte(line[i])
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func cmTableAligns(line string) (aligns []Align) {
+	for _, cell := range cmTableRow(line) {
+		align := AlignLeft
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			align = AlignCenter
+		case strings.HasSuffix(cell, ":"):
+			align = AlignRight
+		}
+		aligns = append(aligns, align)
+	}
+	return aligns
+}
+
+func cmTable(rows [][]string, aligns []Align) Markdown {
+	for r, row := range rows {
+		// rows are cut or padded to the header columns
+		cells := make([]string, len(aligns))
+		for c := range cells {
+			if c < len(row) {
+				cells[c] = PlainText(cmInline(row[c]).s, ParseModeMarkdownV2)
+			}
+		}
+		rows[r] = cells
+	}
+	return RawMarkdown(Table(rows, TableOptions{Align: aligns, Header: true}))
+}
+
+func cmPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
+
+func cmAlnum(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	if i > 0 && !utf8.RuneStart(s[i]) {
+		r, _ = utf8.DecodeLastRuneInString(s[:i+1])
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func cmSpace(s string, i int) bool {
+	return i < 0 || i >= len(s) || strings.IndexByte(" \t\n", s[i]) >= 0
+}
+
+func cmRun(s string, i int) int {
+	n := 0
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+// cmCodeEnd returns the end of the code span opened by the backticks at i or -1.
+func cmCodeEnd(s string, i int) int {
+	n := cmRun(s, i)
+	for j := i + n; j < len(s); {
+		k := strings.IndexByte(s[j:], '`')
+		if k < 0 {
+			return -1
+		}
+		j += k
+		if m := cmRun(s, j); m == n {
+			return j
+		} else {
+			j += m
+		}
+	}
+	return -1
+}
+
+// cmCloser finds the closing delimiter run of length n matching the opener at i.
+func cmCloser(s string, i, n int) int {
+	c := s[i]
+	for j := i + n; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			if k := cmCodeEnd(s, j); k >= 0 {
+				j = k + cmRun(s, k)
+				continue
+			}
+		case c:
+			m := cmRun(s, j)
+			if m == n && !cmSpace(s, j-1) && (c != '_' || !cmAlnum(s, j+m)) {
+				return j
+			}
+			j += m
+			continue
+		}
+		j++
+	}
+	return -1
+}
+
+// cmBracket finds the bracket closing the one at i.
+func cmBracket(s string, i int) int {
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '`':
+			if k := cmCodeEnd(s, j); k >= 0 {
+				j = k + cmRun(s, k) - 1
+			}
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+func cmInline(s string) Markdown {
+	var mm []Markdown
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			mm = append(mm, MarkdownText(text.String()))
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && cmPunct(s[i+1]):
+			i++
+			text.WriteByte(s[i])
+			continue
+
+		case c == '`':
+			n := cmRun(s, i)
+			if j := cmCodeEnd(s, i); j >= 0 {
+				code := strings.ReplaceAll(s[i+n:j], NL, SP)
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+					code = code[1 : len(code)-1]
+				}
+				flush()
+				mm = append(mm, MarkdownCode(code))
+				i = j + n - 1
+			} else {
+				text.WriteString(s[i : i+n])
+				i += n - 1
+			}
+			continue
+
+		case c == '[' || c == '!' && i+1 < len(s) && s[i+1] == '[':
+			start := i
+			if c == '!' {
+				start++
+			}
+			if j := cmBracket(s, start); j >= 0 {
+				if m := cmLinkDestRe.FindStringSubmatch(s[j+1:]); m != nil {
+					url := strings.TrimSuffix(strings.TrimPrefix(m[1], "<"), ">")
+					label := cmInline(s[start+1 : j])
+					if label.s == "" {
+						label = MarkdownText(url)
+					}
+					flush()
+					if url == "" {
+						mm = append(mm, label)
+					} else {
+						mm = append(mm, label.Link(url))
+					}
+					i = j + len(m[0])
+					continue
+				}
+			}
+
+		case c == '<':
+			if m := cmAutolinkRe.FindStringSubmatch(s[i:]); m != nil {
+				text.WriteString(m[1])
+				i += len(m[0]) - 1
+				continue
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			n := cmRun(s, i)
+			opener := !cmSpace(s, i+n) && (c != '_' || !cmAlnum(s, i-1))
+			if c == '~' && n != 2 {
+				opener = false
+			}
+			if opener && n <= 3 {
+				if j := cmCloser(s, i, n); j >= 0 {
+					inner := cmInline(s[i+n : j])
+					flush()
+					switch {
+					case c == '~':
+						mm = append(mm, inner.Strikethrough())
+					case n == 1:
+						mm = append(mm, inner.Italic())
+					case n == 2:
+						mm = append(mm, inner.Bold())
+					default:
+						mm = append(mm, inner.Italic().Bold())
+					}
+					i = j + n - 1
+					continue
+				}
+			}
+			text.WriteString(s[i : i+n])
+			i += n - 1
+			continue
+		}
+
+		text.WriteByte(c)
+	}
+	flush()
+
+	return JoinMarkdown(mm...)
+}
@@ -0,0 +1,47 @@
+package tg
+
+import (
+	"testing"
+)
+
+func TestCommonMark(t *testing.T) {
+
+	for _, tc := range []struct {
+		in  string
+		out string
+	}{
+		{"# Title", "__*Title*__"},
+		{"Title\n---\ntext", "*Title*\n\ntext"},
+		{"### Notes ###", "*_Notes_*"},
+		{"Some **bold**, *italic* and ***both***.", "Some *bold*, _italic_ and *_both_*\\."},
+		{"snake_case and __init__ 2*3*4", "snake\\_case and *init* 2_3_4"},
+		{"~~gone~~ `a*b` ``x ` y``", "~gone~ `a*b` `x \\` y`"},
+		{"[docs](https://example.org/a_(b) \"title\") ![cat](cat.png) <https://x.org>", "[docs](https://example.org/a_(b\\)) [cat](cat.png) https://x\\.org"},
+		{"one\ntwo  \nthree\\\nfour", "one two\nthree\nfour"},
+		{"\\*not bold\\* 1. 2! (x)", "\\*not bold\\* 1\\. 2\\! \\(x\\)"},
+		{"- a\n- b\n  - c\n    1. d\n- [ ] e", "• a\n• b\n  ◦ c\n    1\\. d\n• ☐ e"},
+		{"3. three\n4. four", "3\\. three\n4\\. four"},
+		{"> quoted **text**\n> > nested\n\nafter", ">quoted *text*\n>\n>nested\n\nafter"},
+		{"```go\nfmt.Println(`x`)\n```", "```go\nfmt.Println(\\`x\\`)\n```"},
+		{"text\n\n    indented\n    code", "text\n\n```\nindented\ncode\n```"},
+		{"a\n\n***\n\nb", "a\n\n——————————\n\nb"},
+		{
+			"| Service | Status | Latency |\n|:--|:-:|--:|\n| api | **ok** | 12ms |\n| db | down | 1s |",
+			"```\nService  Status  Latency\n-------  ------  -------\napi        ok       12ms\ndb        down        1s\n```",
+		},
+	} {
+		out := CommonMark(tc.in).String()
+		if out != tc.out {
+			t.Errorf("CommonMark %q == %q expected %q", tc.in, out, tc.out)
+		}
+		if err := ValidateMarkdown(out); err != nil {
+			t.Errorf("%q: %v", out, err)
+		}
+	}
+
+	text, entities := CommonMarkEntities("**a** [b](https://b.org)")
+	if text != "a b" || len(entities) != 2 || entities[0].Type != EntityTypeBold || entities[1].Url != "https://b.org" {
+		t.Errorf("CommonMarkEntities %q %+v", text, entities)
+	}
+
+}
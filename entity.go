@@ -0,0 +1,147 @@
+package tg
+
+import (
+	"unicode/utf8"
+)
+
+// https://core.telegram.org/bots/api#messageentity
+// entity offsets and lengths are measured in UTF-16 code units
+
+const (
+	EntityTypeMention              = "mention"
+	EntityTypeHashtag              = "hashtag"
+	EntityTypeCashtag              = "cashtag"
+	EntityTypeBotCommand           = "bot_command"
+	EntityTypeUrl                  = "url"
+	EntityTypeEmail                = "email"
+	EntityTypePhoneNumber          = "phone_number"
+	EntityTypeBold                 = "bold"
+	EntityTypeItalic               = "italic"
+	EntityTypeUnderline            = "underline"
+	EntityTypeStrikethrough        = "strikethrough"
+	EntityTypeSpoiler              = "spoiler"
+	EntityTypeBlockquote           = "blockquote"
+	EntityTypeExpandableBlockquote = "expandable_blockquote"
+	EntityTypeCode                 = "code"
+	EntityTypePre                  = "pre"
+	EntityTypeTextLink             = "text_link"
+	EntityTypeTextMention          = "text_mention"
+	EntityTypeCustomEmoji          = "custom_emoji"
+)
+
+func utf16len(r rune) int {
+	if r >= 0x10000 && r <= utf8.MaxRune {
+		return 2
+	}
+	return 1
+}
+
+// Utf16Len returns the length of s in UTF-16 code units.
+func Utf16Len(s string) (n int) {
+	for _, r := range s {
+		n += utf16len(r)
+	}
+	return n
+}
+
+// Utf16ToByteOffset converts an offset in UTF-16 code units to a byte offset in s.
+// An offset in the middle of a surrogate pair is moved to the start of the character,
+// an offset past the end of s is clipped to len(s).
+func Utf16ToByteOffset(s string, offset int) int {
+	n := 0
+	for i, r := range s {
+		n += utf16len(r)
+		if n > offset {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// ByteToUtf16Offset converts a byte offset in s to an offset in UTF-16 code units.
+func ByteToUtf16Offset(s string, offset int) int {
+	if offset > len(s) {
+		offset = len(s)
+	}
+	return Utf16Len(s[:offset])
+}
+
+// Utf16ToRuneOffset converts an offset in UTF-16 code units to an offset in runes of s.
+func Utf16ToRuneOffset(s string, offset int) int {
+	return utf8.RuneCountInString(s[:Utf16ToByteOffset(s, offset)])
+}
+
+// RuneToUtf16Offset converts an offset in runes of s to an offset in UTF-16 code units.
+func RuneToUtf16Offset(s string, offset int) (n int) {
+	for _, r := range s {
+		if offset <= 0 {
+			break
+		}
+		n += utf16len(r)
+		offset--
+	}
+	return n
+}
+
+// Text returns the part of text the entity covers.
+func (e MessageEntity) Text(text string) string {
+	start := Utf16ToByteOffset(text, e.Offset)
+	end := Utf16ToByteOffset(text, e.Offset+e.Length)
+	return text[start:end]
+}
+
+// EntityTexts returns the parts of the text or caption covered by the entities of the given types.
+func (m *Message) EntityTexts(types ...string) (ss []string) {
+	for _, te := range []struct {
+		text     string
+		entities []MessageEntity
+	}{
+		{m.Text, m.Entities},
+		{m.Caption, m.CaptionEntities},
+	} {
+		for _, e := range te.entities {
+			for _, typ := range types {
+				if e.Type == typ {
+					ss = append(ss, e.Text(te.text))
+					break
+				}
+			}
+		}
+	}
+	return ss
+}
+
+// URLs returns the urls of the text or caption including the ones hidden behind text links.
+func (m *Message) URLs() (urls []string) {
+	for _, te := range []struct {
+		text     string
+		entities []MessageEntity
+	}{
+		{m.Text, m.Entities},
+		{m.Caption, m.CaptionEntities},
+	} {
+		for _, e := range te.entities {
+			switch e.Type {
+			case EntityTypeUrl:
+				urls = append(urls, e.Text(te.text))
+			case EntityTypeTextLink:
+				urls = append(urls, e.Url)
+			}
+		}
+	}
+	return urls
+}
+
+func (m *Message) Hashtags() []string {
+	return m.EntityTexts(EntityTypeHashtag)
+}
+
+// Mentions returns @username mentions of the text or caption.
+// Mentions of users without usernames are text_mention entities with the User set.
+func (m *Message) Mentions() []string {
+	return m.EntityTexts(EntityTypeMention)
+}
+
+func (m *Message) Commands() []string {
+	return m.EntityTexts(EntityTypeBotCommand)
+}
@@ -0,0 +1,60 @@
+package tg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUtf16(t *testing.T) {
+
+	s := "a😀bé"
+	if n := Utf16Len(s); n != 5 {
+		t.Errorf("Utf16Len %d", n)
+	}
+	for _, tc := range []struct{ utf16, byte, rune int }{
+		{0, 0, 0}, {1, 1, 1}, {3, 5, 2}, {4, 6, 3}, {5, 8, 4},
+	} {
+		if b := Utf16ToByteOffset(s, tc.utf16); b != tc.byte {
+			t.Errorf("Utf16ToByteOffset %d == %d expected %d", tc.utf16, b, tc.byte)
+		}
+		if u := ByteToUtf16Offset(s, tc.byte); u != tc.utf16 {
+			t.Errorf("ByteToUtf16Offset %d == %d expected %d", tc.byte, u, tc.utf16)
+		}
+		if r := Utf16ToRuneOffset(s, tc.utf16); r != tc.rune {
+			t.Errorf("Utf16ToRuneOffset %d == %d expected %d", tc.utf16, r, tc.rune)
+		}
+		if u := RuneToUtf16Offset(s, tc.rune); u != tc.utf16 {
+			t.Errorf("RuneToUtf16Offset %d == %d expected %d", tc.rune, u, tc.utf16)
+		}
+	}
+
+}
+
+func TestMessageEntities(t *testing.T) {
+
+	m := Message{
+		Text: "😀 /start@bot see https://example.org #tag @user",
+		Entities: []MessageEntity{
+			{Type: EntityTypeBotCommand, Offset: 3, Length: 10},
+			{Type: EntityTypeUrl, Offset: 18, Length: 19},
+			{Type: EntityTypeHashtag, Offset: 38, Length: 4},
+			{Type: EntityTypeMention, Offset: 43, Length: 5},
+		},
+		Caption:         "link",
+		CaptionEntities: []MessageEntity{{Type: EntityTypeTextLink, Offset: 0, Length: 4, Url: "https://example.com"}},
+	}
+
+	if cc := m.Commands(); !reflect.DeepEqual(cc, []string{"/start@bot"}) {
+		t.Errorf("Commands %q", cc)
+	}
+	if uu := m.URLs(); !reflect.DeepEqual(uu, []string{"https://example.org", "https://example.com"}) {
+		t.Errorf("URLs %q", uu)
+	}
+	if hh := m.Hashtags(); !reflect.DeepEqual(hh, []string{"#tag"}) {
+		t.Errorf("Hashtags %q", hh)
+	}
+	if mm := m.Mentions(); !reflect.DeepEqual(mm, []string{"@user"}) {
+		t.Errorf("Mentions %q", mm)
+	}
+
+}
@@ -0,0 +1,124 @@
+package tg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// isParseError reports if the api error description is about a formatting error.
+func isParseError(description string) bool {
+	// Bad Request: can't parse entities: Character '.' is reserved and must be escaped with the preceding '\'
+	return strings.Contains(strings.ToLower(description), "can't parse entities")
+}
+
+// PlainText strips the formatting of text in the parse mode keeping its content.
+// Malformed MarkdownV2 is tolerated by taking the offending characters literally.
+func PlainText(text, parseMode string) string {
+	switch parseMode {
+	case ParseModeMarkdownV2:
+		return plainMarkdown(text)
+	case ParseModeHTML:
+		return plainHtml(text)
+	}
+	return text
+}
+
+func plainMarkdown(s string) string {
+	literal := make(map[int]bool)
+	for {
+		text, _, err := parseMarkdown(s, literal)
+		if err == nil {
+			return text
+		}
+		var mderr *MarkdownError
+		if !errors.As(err, &mderr) || literal[mderr.Offset] || mderr.Offset >= len(s) {
+			break
+		}
+		literal[mderr.Offset] = true
+	}
+
+	// unescape only
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && s[i+1] > 0 && s[i+1] <= 126 {
+			i++
+		}
+		if s[i] != '\r' {
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func plainHtml(s string) string {
+	// https://core.telegram.org/bots/api#html-style
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(s[i:], '>')
+		if j < 0 {
+			break
+		}
+		b.WriteString(s[:i])
+		s = s[i+j+1:]
+	}
+	b.WriteString(s)
+	return html.UnescapeString(b.String())
+}
+
+// withParseFallback calls send and if it fails with a parse error and fallback is set
+// strips the formatting of text and calls send again without parse mode and entities.
+// It reports if the text was resent as plain text.
+func withParseFallback(fallback bool, parseMode, text *string, entities *[]MessageEntity, send func() error) (resent bool, err error) {
+	return withPlainFallback(fallback, func() bool {
+		if *parseMode == "" {
+			return false
+		}
+		*text, *parseMode, *entities = PlainText(*text, *parseMode), "", []MessageEntity{}
+		return true
+	}, send)
+}
+
+// withPlainFallback calls send and if it fails with a parse error and fallback is set
+// calls send again after strip reports it has stripped the formatting.
+func withPlainFallback(fallback bool, strip func() bool, send func() error) (resent bool, err error) {
+	err = send()
+	if err == nil || !fallback || !isParseError(err.Error()) || !strip() {
+		return false, err
+	}
+	perr(F("ERROR %v, resending as plain text", err))
+	if err := send(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// rereader returns a function giving r to read from where it is now on every call
+// if again is set, seekable readers are rewound and others are read into memory.
+func rereader(r io.Reader, again bool) (func() (io.Reader, error), error) {
+	if !again {
+		return func() (io.Reader, error) { return r, nil }, nil
+	}
+	if rs, ok := r.(io.ReadSeeker); ok {
+		if pos, err := rs.Seek(0, io.SeekCurrent); err == nil {
+			return func() (io.Reader, error) {
+				if _, err := rs.Seek(pos, io.SeekStart); err != nil {
+					return nil, fmt.Errorf("Seek %w", err)
+				}
+				return rs, nil
+			}, nil
+		}
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("ReadAll %w", err)
+	}
+	return func() (io.Reader, error) { return bytes.NewReader(data), nil }, nil
+}
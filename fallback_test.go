@@ -0,0 +1,192 @@
+package tg
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPlainText(t *testing.T) {
+
+	for _, tc := range []struct {
+		text      string
+		parseMode string
+		plain     string
+	}{
+		{"*bold* and _italic_\\!", ParseModeMarkdownV2, "bold and italic!"},
+		{"version 1.2 is *out*", ParseModeMarkdownV2, "version 1.2 is out"},
+		{"*unclosed bold", ParseModeMarkdownV2, "*unclosed bold"},
+		{"a [link](https://example.org", ParseModeMarkdownV2, "a [link](https://example.org"},
+		{">quote\n>lines", ParseModeMarkdownV2, "quote\nlines"},
+		{"<b>a &lt; b</b> &amp; <a href=\"x\">c</a>", ParseModeHTML, "a < b & c"},
+		{"<b>unclosed", ParseModeHTML, "unclosed"},
+		{"*as is*", "", "*as is*"},
+	} {
+		if plain := PlainText(tc.text, tc.parseMode); plain != tc.plain {
+			t.Errorf("PlainText %q %s == %q expected %q", tc.text, tc.parseMode, plain, tc.plain)
+		}
+	}
+
+}
+
+func TestSendMessageParseFallback(t *testing.T) {
+
+	var reqs []map[string]interface{}
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		reqs = append(reqs, req)
+		if _, ok := req["parse_mode"]; ok {
+			w.Write([]byte(`{"ok":false,"description":"Bad Request: can't parse entities: Character '.' is reserved and must be escaped with the preceding '\\'"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	})
+
+	if _, err := SendMessage(SendMessageRequest{ChatId: "1", Text: "*disk* 99.9%"}); err == nil {
+		t.Errorf("no error without ParseFallback")
+	}
+
+	reqs = nil
+	msg, err := SendMessage(SendMessageRequest{ChatId: "1", Text: "*disk* 99.9%", ParseFallback: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !msg.ParseFallback || len(reqs) != 2 || reqs[1]["text"] != "disk 99.9%" {
+		t.Errorf("ParseFallback %v requests %v", msg.ParseFallback, reqs)
+	}
+
+}
+
+func TestWithParseFallback(t *testing.T) {
+
+	for _, tc := range []struct {
+		fallback  bool
+		parseMode string
+		errs      []error
+		resent    bool
+		sends     int
+		text      string
+	}{
+		{true, ParseModeMarkdownV2, []error{nil}, false, 1, "*a* 1.5"},
+		{true, ParseModeMarkdownV2, []error{errors.New("sendMessage Bad Request: can't parse entities: Character '.' is reserved"), nil}, true, 2, "a 1.5"},
+		{false, ParseModeMarkdownV2, []error{errors.New("sendMessage Bad Request: can't parse entities")}, false, 1, "*a* 1.5"},
+		{true, "", []error{errors.New("sendMessage Bad Request: can't parse entities")}, false, 1, "*a* 1.5"},
+		{true, ParseModeMarkdownV2, []error{errors.New("sendMessage Bad Request: chat not found")}, false, 1, "*a* 1.5"},
+	} {
+		parseMode, text, entities := tc.parseMode, "*a* 1.5", []MessageEntity(nil)
+		var sends int
+		resent, err := withParseFallback(tc.fallback, &parseMode, &text, &entities, func() error {
+			sends++
+			return tc.errs[sends-1]
+		})
+		if resent != tc.resent || sends != tc.sends || text != tc.text || (err == nil) != (tc.errs[sends-1] == nil) {
+			t.Errorf("withParseFallback %v %q == %v sends %d text %q err %v expected %v sends %d text %q", tc.fallback, tc.parseMode, resent, sends, text, err, tc.resent, tc.sends, tc.text)
+		}
+		if resent && (parseMode != "" || entities == nil) {
+			t.Errorf("resent with parse mode %q entities %v", parseMode, entities)
+		}
+	}
+
+}
+
+func TestSendFileParseFallback(t *testing.T) {
+
+	type upload struct{ caption, parseMode, file string }
+	var uploads []upload
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatal(err)
+		}
+		var file string
+		for _, name := range []string{"audio", "video", "voice", "photo"} {
+			if f, _, err := r.FormFile(name); err == nil {
+				data, _ := io.ReadAll(f)
+				file = string(data)
+			}
+		}
+		uploads = append(uploads, upload{r.FormValue("caption"), r.FormValue("parse_mode"), file})
+		if r.FormValue("parse_mode") != "" {
+			w.Write([]byte(`{"ok":false,"description":"Bad Request: can't parse entities: Character '.' is reserved and must be escaped with the preceding '\\'"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"audio":{"file_id":"a"},"video":{"file_id":"v"},"voice":{"file_id":"o"},"photo":[{"file_id":"p"}]}}`))
+	})
+
+	caption := "*disk* 99.9%"
+	for name, send := range map[string]func(r io.Reader, fallback bool) (*Message, error){
+		"SendAudioFile": func(r io.Reader, fallback bool) (*Message, error) {
+			return SendAudioFile(SendAudioFileRequest{ChatId: "1", Caption: caption, ParseMode: ParseModeMarkdownV2, Audio: r, ParseFallback: fallback})
+		},
+		"SendVideoFile": func(r io.Reader, fallback bool) (*Message, error) {
+			return SendVideoFile(SendVideoFileRequest{ChatId: "1", Caption: caption, ParseMode: ParseModeMarkdownV2, Video: r, ParseFallback: fallback})
+		},
+		"SendVoiceFile": func(r io.Reader, fallback bool) (*Message, error) {
+			return SendVoiceFile(SendVoiceFileRequest{ChatId: "1", Caption: caption, ParseMode: ParseModeMarkdownV2, Voice: r, ParseFallback: fallback})
+		},
+		"SendPhotoFile": func(r io.Reader, fallback bool) (*Message, error) {
+			return SendPhotoFile(SendPhotoFileRequest{ChatId: "1", FileName: "photo.jpg", Caption: caption, ParseMode: ParseModeMarkdownV2, Photo: r, ParseFallback: fallback})
+		},
+	} {
+		uploads = nil
+		if _, err := send(strings.NewReader("data"), false); err == nil || len(uploads) != 1 {
+			t.Errorf("%s without ParseFallback err %v uploads %v", name, err, uploads)
+		}
+
+		// readers that can not seek are read into memory for the resend
+		for _, r := range []io.Reader{strings.NewReader("data"), io.LimitReader(strings.NewReader("data"), 4)} {
+			uploads = nil
+			msg, err := send(r, true)
+			if err != nil {
+				t.Fatalf("%s %v", name, err)
+			}
+			if !msg.ParseFallback || len(uploads) != 2 || uploads[1] != (upload{"disk 99.9%", "", "data"}) {
+				t.Errorf("%s ParseFallback %v uploads %v", name, msg.ParseFallback, uploads)
+			}
+		}
+	}
+
+}
+
+func TestSendMediaGroupParseFallback(t *testing.T) {
+
+	var medias [][]inputMedia
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		var media []inputMedia
+		if err := json.Unmarshal([]byte(r.FormValue("media")), &media); err != nil {
+			t.Fatal(err)
+		}
+		medias = append(medias, media)
+		for _, m := range media {
+			if m.ParseMode != "" {
+				w.Write([]byte(`{"ok":false,"description":"Bad Request: can't parse entities: Character '.' is reserved and must be escaped with the preceding '\\'"}`))
+				return
+			}
+		}
+		w.Write([]byte(`{"ok":true,"result":[{"message_id":1},{"message_id":2}]}`))
+	})
+
+	mm, err := SendMediaGroup(SendMediaGroupRequest{
+		ChatId: "1",
+		Media: []InputMedia{
+			InputMediaPhoto{File: io.LimitReader(strings.NewReader("photo"), 5), Caption: "*disk* 99.9%", ParseMode: ParseModeMarkdownV2},
+			InputMediaPhoto{Media: "fileid", Caption: "as is", CaptionEntities: []MessageEntity{{Type: EntityTypeBold, Offset: 0, Length: 2}}},
+		},
+		ParseFallback: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(medias) != 2 || medias[1][0].Caption != "disk 99.9%" || medias[1][1].Caption != "as is" || len(medias[1][1].CaptionEntities) != 1 {
+		t.Errorf("media %+v", medias)
+	}
+	if len(mm) != 2 || !mm[0].ParseFallback || !mm[1].ParseFallback {
+		t.Errorf("messages %+v", mm)
+	}
+
+}
@@ -0,0 +1,107 @@
+package tg
+
+import (
+	"strings"
+)
+
+// HtmlEsc escapes text for the HTML parse mode.
+func HtmlEsc(text string) string {
+	// https://core.telegram.org/bots/api#html-style
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(text)
+}
+
+// Html is text for the HTML parse mode.
+// The helpers take Html for formatted text they wrap as is
+// and string for plain text they escape.
+type Html string
+
+// HtmlText escapes plain text.
+func HtmlText(s string) Html {
+	return Html(HtmlEsc(s))
+}
+
+func HtmlTextf(format string, a ...interface{}) Html {
+	return HtmlText(F(format, a...))
+}
+
+func (h Html) String() string {
+	return string(h)
+}
+
+func HtmlBold(text Html) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return "<b>" + text + "</b>"
+}
+
+func HtmlItalic(text Html) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return "<i>" + text + "</i>"
+}
+
+func HtmlUnderline(text Html) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return "<u>" + text + "</u>"
+}
+
+func HtmlBoldUnderline(text Html) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return "<u><b>" + text + "</b></u>"
+}
+
+func HtmlItalicUnderline(text Html) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return "<i><u>" + text + "</u></i>"
+}
+
+func HtmlStrikethrough(text Html) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return "<s>" + text + "</s>"
+}
+
+func HtmlSpoiler(text Html) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return "<tg-spoiler>" + text + "</tg-spoiler>"
+}
+
+func HtmlCode(text string) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return Html("<code>" + HtmlEsc(text) + "</code>")
+}
+
+func HtmlLink(text Html, url string) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return Html(`<a href="`+HtmlEsc(url)+`">`) + text + "</a>"
+}
+
+func HtmlTextMention(text Html, userid int64) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return Html(F(`<a href="tg://user?id=%d">`, userid)) + text + "</a>"
+}
+
+func HtmlCustomEmoji(id, emoji string) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return Html(`<tg-emoji emoji-id="` + HtmlEsc(id) + `">` + HtmlEsc(emoji) + "</tg-emoji>")
+}
+
+func HtmlPre(text string) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return Html("<pre>" + HtmlEsc(text) + "</pre>")
+}
+
+func HtmlPreLang(lang, text string) Html {
+	// https://core.telegram.org/bots/api#html-style
+	if lang == "" {
+		return HtmlPre(text)
+	}
+	return Html(`<pre><code class="language-` + HtmlEsc(lang) + `">` + HtmlEsc(text) + "</code></pre>")
+}
+
+func HtmlQuote(text Html) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return "<blockquote>" + text + "</blockquote>"
+}
+
+func HtmlExpandQuote(text Html) Html {
+	// https://core.telegram.org/bots/api#html-style
+	return "<blockquote expandable>" + text + "</blockquote>"
+}
@@ -0,0 +1,72 @@
+package tg
+
+import (
+	"testing"
+)
+
+func TestHtml(t *testing.T) {
+
+	for _, tc := range []struct {
+		text     string
+		entities []MessageEntity
+		html     Html
+	}{
+		{
+			text:     "a<b & \"c\"",
+			entities: []MessageEntity{{Type: EntityTypeBold, Offset: 0, Length: 9}},
+			html:     HtmlBold(HtmlText("a<b & \"c\"")),
+		},
+		{
+			text:     "x<y",
+			entities: []MessageEntity{{Type: EntityTypeCode, Offset: 0, Length: 3}},
+			html:     HtmlCode("x<y"),
+		},
+		{
+			text:     "if a < b {}",
+			entities: []MessageEntity{{Type: EntityTypePre, Offset: 0, Length: 11, Language: "go"}},
+			html:     HtmlPreLang("go", "if a < b {}"),
+		},
+		{
+			text:     "docs",
+			entities: []MessageEntity{{Type: EntityTypeTextLink, Offset: 0, Length: 4, Url: "https://example.org/?a=1&b=\"2\""}},
+			html:     HtmlLink(HtmlText("docs"), "https://example.org/?a=1&b=\"2\""),
+		},
+		{
+			text:     "Bob",
+			entities: []MessageEntity{{Type: EntityTypeTextMention, Offset: 0, Length: 3, User: &User{Id: 42}}},
+			html:     HtmlTextMention("Bob", 42),
+		},
+		{
+			text:     "👍",
+			entities: []MessageEntity{{Type: EntityTypeCustomEmoji, Offset: 0, Length: 2, CustomEmojiId: "5368324170671202286"}},
+			html:     HtmlCustomEmoji("5368324170671202286", "👍"),
+		},
+		{
+			text: "line1\nline2",
+			entities: []MessageEntity{
+				{Type: EntityTypeExpandableBlockquote, Offset: 0, Length: 11},
+				{Type: EntityTypeSpoiler, Offset: 6, Length: 5},
+			},
+			html: HtmlExpandQuote("line1\n" + HtmlSpoiler("line2")),
+		},
+	} {
+		if html := Html(EntitiesHTML(tc.text, tc.entities)); html != tc.html {
+			t.Errorf("EntitiesHTML %q %+v == %q expected %q", tc.text, tc.entities, html, tc.html)
+		}
+	}
+
+}
+
+func TestHtmlText(t *testing.T) {
+
+	h := HtmlLink(HtmlBold(HtmlText("a&b"))+HtmlText(" <c>")+HtmlCode("<d>"), "https://example.org/?q=\"x\"")
+	expected := Html(`<a href="https://example.org/?q=&quot;x&quot;"><b>a&amp;b</b> &lt;c&gt;<code>&lt;d&gt;</code></a>`)
+	if h != expected {
+		t.Errorf("HtmlLink %q expected %q", h, expected)
+	}
+
+	if h := HtmlItalic(HtmlTextf("%d < %d", 1, 2)); h != "<i>1 &lt; 2</i>" {
+		t.Errorf("HtmlItalic %q", h)
+	}
+
+}
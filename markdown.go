@@ -0,0 +1,373 @@
+package tg
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// https://core.telegram.org/bots/api#markdownv2-style
+// https://github.com/tdlib/td/blob/master/td/telegram/MessageEntity.cpp parse_markdown_v2
+
+const (
+	markdownReserved     = "_*[]()~`>#+-=|{}.!"
+	markdownReservedCode = "`"
+)
+
+// MarkdownError is a MarkdownV2 syntax error at the given position of the source text.
+type MarkdownError struct {
+	// byte offset
+	Offset int
+	// line and column in runes, starting from 1
+	Line, Column int
+
+	Message string
+}
+
+func (e *MarkdownError) Error() string {
+	return F("line %d column %d byte offset %d: %s", e.Line, e.Column, e.Offset, e.Message)
+}
+
+func markdownError(s string, offset int, format string, a ...interface{}) *MarkdownError {
+	if offset > len(s) {
+		offset = len(s)
+	}
+	linestart := strings.LastIndexByte(s[:offset], '\n') + 1
+	return &MarkdownError{
+		Offset:  offset,
+		Line:    strings.Count(s[:offset], NL) + 1,
+		Column:  utf8.RuneCountInString(s[linestart:offset]) + 1,
+		Message: F(format, a...),
+	}
+}
+
+func markdownEntityName(typ string) string {
+	switch typ {
+	case EntityTypeBold:
+		return "Bold"
+	case EntityTypeItalic:
+		return "Italic"
+	case EntityTypeUnderline:
+		return "Underline"
+	case EntityTypeStrikethrough:
+		return "Strikethrough"
+	case EntityTypeSpoiler:
+		return "Spoiler"
+	case EntityTypeCode:
+		return "Code"
+	case EntityTypePre:
+		return "Pre"
+	case EntityTypeTextLink:
+		return "TextUrl"
+	case EntityTypeCustomEmoji:
+		return "CustomEmoji"
+	case EntityTypeBlockquote:
+		return "BlockQuote"
+	}
+	return typ
+}
+
+type markdownOpen struct {
+	typ      string
+	language string
+	// utf16 offset of the entity in the text
+	offset int
+	// byte offset of the entity in the source and in the text
+	srcpos, textpos int
+}
+
+// ParseMarkdown parses MarkdownV2 the way telegram does
+// and returns the plain text with its entities or a *MarkdownError.
+func ParseMarkdown(s string) (text string, entities []MessageEntity, err error) {
+	return parseMarkdown(s, nil)
+}
+
+// ValidateMarkdown reports the first MarkdownV2 syntax error of s as a *MarkdownError.
+func ValidateMarkdown(s string) error {
+	_, _, err := parseMarkdown(s, nil)
+	return err
+}
+
+// parseMarkdown treats the bytes at the literal offsets of s as plain text.
+func parseMarkdown(s string, literal map[int]bool) (text string, entities []MessageEntity, err error) {
+	var result []byte
+	var offset int
+	var stack []markdownOpen
+
+	char := func(c byte) {
+		// telegram removes carriage returns from the text
+		if c == '\r' {
+			return
+		}
+		result = append(result, c)
+		if c&0xc0 != 0x80 {
+			offset++
+			if c >= 0xf0 {
+				offset++
+			}
+		}
+	}
+
+	quote := func() int {
+		for i, o := range stack {
+			if o.typ == EntityTypeBlockquote {
+				return i
+			}
+		}
+		return -1
+	}
+
+	closequote := func(end int, typ string) error {
+		q := quote()
+		if q != len(stack)-1 {
+			o := stack[len(stack)-1]
+			return markdownError(s, o.srcpos, "Can't find end of %s entity at byte offset %d", markdownEntityName(o.typ), o.srcpos)
+		}
+		if end > stack[q].offset {
+			entities = append(entities, MessageEntity{Type: typ, Offset: stack[q].offset, Length: end - stack[q].offset})
+		}
+		stack = stack[:q]
+		return nil
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if literal[i] {
+			char(c)
+			continue
+		}
+
+		if c == '\\' && i+1 < len(s) && s[i+1] > 0 && s[i+1] <= 126 {
+			i++
+			char(s[i])
+			continue
+		}
+
+		if c == '\n' && quote() >= 0 {
+			end := offset
+			char(c)
+			if i+1 < len(s) && s[i+1] == '>' && !literal[i+1] {
+				i++
+			} else if err := closequote(end, EntityTypeBlockquote); err != nil {
+				return "", nil, err
+			}
+			continue
+		}
+
+		reserved := markdownReserved
+		if len(stack) > 0 {
+			switch stack[len(stack)-1].typ {
+			case EntityTypeCode, EntityTypePre:
+				reserved = markdownReservedCode
+			}
+		}
+		if strings.IndexByte(reserved, c) < 0 {
+			char(c)
+			continue
+		}
+
+		if c == '>' && (len(result) == 0 || result[len(result)-1] == '\n') && len(stack) == 0 {
+			stack = append(stack, markdownOpen{typ: EntityTypeBlockquote, offset: offset, srcpos: i, textpos: len(result)})
+			continue
+		}
+
+		// the expandability mark at the end of the last quote line
+		if c == '|' && i+1 < len(s) && s[i+1] == '|' && (i+2 == len(s) || s[i+2] == '\n') &&
+			len(stack) > 0 && stack[len(stack)-1].typ == EntityTypeBlockquote {
+			if err := closequote(offset, EntityTypeExpandableBlockquote); err != nil {
+				return "", nil, err
+			}
+			i++
+			continue
+		}
+
+		next := func(k int) byte {
+			if i+k < len(s) {
+				return s[i+k]
+			}
+			return 0
+		}
+
+		isend := false
+		if len(stack) > 0 {
+			switch stack[len(stack)-1].typ {
+			case EntityTypeBold:
+				isend = c == '*'
+			case EntityTypeItalic:
+				isend = c == '_' && next(1) != '_'
+			case EntityTypeCode:
+				isend = c == '`'
+			case EntityTypePre:
+				isend = c == '`' && next(1) == '`' && next(2) == '`'
+			case EntityTypeTextLink, EntityTypeCustomEmoji:
+				isend = c == ']'
+			case EntityTypeUnderline:
+				isend = c == '_' && next(1) == '_'
+			case EntityTypeStrikethrough:
+				isend = c == '~'
+			case EntityTypeSpoiler:
+				isend = c == '|' && next(1) == '|'
+			}
+		}
+
+		if !isend {
+			o := markdownOpen{srcpos: i}
+			switch c {
+			case '_':
+				if next(1) == '_' {
+					o.typ = EntityTypeUnderline
+					i++
+				} else {
+					o.typ = EntityTypeItalic
+				}
+			case '*':
+				o.typ = EntityTypeBold
+			case '~':
+				o.typ = EntityTypeStrikethrough
+			case '|':
+				if next(1) != '|' {
+					return "", nil, markdownError(s, i, "Character '%c' is reserved and must be escaped with the preceding '\\'", c)
+				}
+				o.typ = EntityTypeSpoiler
+				i++
+			case '[':
+				o.typ = EntityTypeTextLink
+			case '`':
+				if next(1) == '`' && next(2) == '`' {
+					o.typ = EntityTypePre
+					i += 3
+					langend := i
+					for langend < len(s) && !isMarkdownSpace(s[langend]) && s[langend] != '`' {
+						langend++
+					}
+					if i != langend && langend < len(s) && s[langend] != '`' {
+						o.language = s[i:langend]
+						i = langend
+					}
+					// skip one new line in the beginning of the text
+					if i < len(s) && (s[i] == '\n' || s[i] == '\r') {
+						if i+1 < len(s) && (s[i+1] == '\n' || s[i+1] == '\r') && s[i] != s[i+1] {
+							i += 2
+						} else {
+							i++
+						}
+					}
+					i--
+				} else {
+					o.typ = EntityTypeCode
+				}
+			case '!':
+				if next(1) != '[' {
+					return "", nil, markdownError(s, i, "Character '%c' is reserved and must be escaped with the preceding '\\'", c)
+				}
+				o.typ = EntityTypeCustomEmoji
+				i++
+			default:
+				return "", nil, markdownError(s, i, "Character '%c' is reserved and must be escaped with the preceding '\\'", c)
+			}
+			o.offset, o.textpos = offset, len(result)
+			stack = append(stack, o)
+			continue
+		}
+
+		o := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		e := MessageEntity{Type: o.typ, Offset: o.offset, Language: o.language}
+
+		switch o.typ {
+		case EntityTypeUnderline, EntityTypeSpoiler:
+			i++
+		case EntityTypePre:
+			i += 2
+			// the new line before the closing backticks is a part of the markup
+			if len(result) > o.textpos && result[len(result)-1] == '\n' {
+				result = result[:len(result)-1]
+				offset--
+			}
+		case EntityTypeTextLink, EntityTypeCustomEmoji:
+			var u string
+			if next(1) != '(' {
+				u = string(result[o.textpos:])
+			} else {
+				i += 2
+				urlstart := i
+				var ub []byte
+				for i < len(s) && s[i] != ')' {
+					if s[i] == '\\' && i+1 < len(s) && s[i+1] > 0 && s[i+1] <= 126 {
+						ub = append(ub, s[i+1])
+						i += 2
+						continue
+					}
+					ub = append(ub, s[i])
+					i++
+				}
+				if i >= len(s) {
+					return "", nil, markdownError(s, urlstart, "Can't find end of a URL at byte offset %d", urlstart)
+				}
+				u = string(ub)
+			}
+			if o.typ == EntityTypeCustomEmoji {
+				id, ok := markdownLinkId(u, "emoji")
+				if !ok {
+					return "", nil, markdownError(s, o.srcpos, "Custom emoji URL must have an emoji identifier")
+				}
+				e.CustomEmojiId = id
+			} else if id, ok := markdownLinkId(u, "user"); ok {
+				userid, _ := strconv.ParseInt(id, 10, 64)
+				e.Type, e.User = EntityTypeTextMention, &User{Id: userid}
+			} else if pu, err := url.Parse(strings.TrimSpace(u)); err != nil || strings.TrimSpace(u) == "" {
+				// telegram silently drops entities with invalid urls
+				continue
+			} else {
+				e.Url = pu.String()
+			}
+		}
+
+		if offset > o.offset {
+			e.Length = offset - o.offset
+			entities = append(entities, e)
+		}
+	}
+
+	if quote() >= 0 {
+		if err := closequote(offset, EntityTypeBlockquote); err != nil {
+			return "", nil, err
+		}
+	}
+	if len(stack) > 0 {
+		o := stack[len(stack)-1]
+		return "", nil, markdownError(s, o.srcpos, "Can't find end of %s entity at byte offset %d", markdownEntityName(o.typ), o.srcpos)
+	}
+
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].Offset != entities[j].Offset {
+			return entities[i].Offset < entities[j].Offset
+		}
+		if entities[i].Length != entities[j].Length {
+			return entities[i].Length > entities[j].Length
+		}
+		return entityNesting(entities[i].Type) < entityNesting(entities[j].Type)
+	})
+
+	return string(result), entities, nil
+}
+
+func isMarkdownSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+// markdownLinkId returns the id of tg://user?id= and tg://emoji?id= links.
+func markdownLinkId(u, host string) (id string, ok bool) {
+	pu, err := url.Parse(u)
+	if err != nil || pu.Scheme != "tg" || pu.Host != host {
+		return "", false
+	}
+	id = pu.Query().Get("id")
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return "", false
+	}
+	return id, true
+}
@@ -0,0 +1,94 @@
+package tg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkdown(t *testing.T) {
+
+	for _, tc := range []struct {
+		md       string
+		text     string
+		entities []MessageEntity
+	}{
+		{Esc("1.5 + (a) = b!"), "1.5 + (a) = b!", nil},
+		{Bold("bold") + " " + Italic(Esc("it.")), "bold it.", []MessageEntity{{Type: EntityTypeBold, Offset: 0, Length: 4}, {Type: EntityTypeItalic, Offset: 5, Length: 3}}},
+		{ItalicUnderline("iu"), " iu ", []MessageEntity{{Type: EntityTypeItalic, Offset: 0, Length: 4}, {Type: EntityTypeUnderline, Offset: 1, Length: 2}}},
+		{"😀" + Spoiler("s"), "😀s", []MessageEntity{{Type: EntityTypeSpoiler, Offset: 2, Length: 1}}},
+		{Code("a`b\\c"), "a`b\\c", []MessageEntity{{Type: EntityTypeCode, Offset: 0, Length: 5}}},
+		{"```go\nfmt.Println(1)\n```", "fmt.Println(1)", []MessageEntity{{Type: EntityTypePre, Offset: 0, Length: 14, Language: "go"}}},
+		{Link("a.b", "https://example.org/(x)"), "a.b", []MessageEntity{{Type: EntityTypeTextLink, Offset: 0, Length: 3, Url: "https://example.org/(x)"}}},
+		{"[name](tg://user?id=42)", "name", []MessageEntity{{Type: EntityTypeTextMention, Offset: 0, Length: 4, User: &User{Id: 42}}}},
+		{"![👍](tg://emoji?id=5368324170671202286)", "👍", []MessageEntity{{Type: EntityTypeCustomEmoji, Offset: 0, Length: 2, CustomEmojiId: "5368324170671202286"}}},
+		{Quote("a"+NL+"b") + "c", "a\nb\nc", []MessageEntity{{Type: EntityTypeBlockquote, Offset: 0, Length: 3}}},
+		{ExpandQuote("a" + NL + Bold("b")), "a\nb\n", []MessageEntity{{Type: EntityTypeExpandableBlockquote, Offset: 0, Length: 3}, {Type: EntityTypeBold, Offset: 2, Length: 1}}},
+		{">q1\n**>q2||", "q1\nq2", []MessageEntity{{Type: EntityTypeBlockquote, Offset: 0, Length: 2}, {Type: EntityTypeExpandableBlockquote, Offset: 3, Length: 2}}},
+		{"_\r__iu__\r_", "iu", []MessageEntity{{Type: EntityTypeItalic, Offset: 0, Length: 2}, {Type: EntityTypeUnderline, Offset: 0, Length: 2}}},
+	} {
+		text, entities, err := ParseMarkdown(tc.md)
+		if err != nil {
+			t.Errorf("ParseMarkdown %q %v", tc.md, err)
+			continue
+		}
+		if text != tc.text || !reflect.DeepEqual(entities, tc.entities) {
+			t.Errorf("ParseMarkdown %q == %q %#v expected %q %#v", tc.md, text, entities, tc.text, tc.entities)
+		}
+
+		md := EntitiesMarkdown(text, entities)
+		text2, entities2, err := ParseMarkdown(md)
+		if err != nil || text2 != text || !reflect.DeepEqual(entities2, entities) {
+			t.Errorf("EntitiesMarkdown %q == %q does not round trip: %q %#v %v", tc.md, md, text2, entities2, err)
+		}
+	}
+
+}
+
+func TestParseMarkdownErrors(t *testing.T) {
+
+	for _, tc := range []struct {
+		md            string
+		offset, line  int
+		column        int
+		messagePrefix string
+	}{
+		{"1.5", 1, 1, 2, "Character '.' is reserved"},
+		{"ok\n😀 a-b", 9, 2, 4, "Character '-' is reserved"},
+		{"*bold", 0, 1, 1, "Can't find end of Bold entity"},
+		{"[link](https://example.org", 7, 1, 8, "Can't find end of a URL"},
+		{"a > b", 2, 1, 3, "Character '>' is reserved"},
+	} {
+		err := ValidateMarkdown(tc.md)
+		var mderr *MarkdownError
+		if !errors.As(err, &mderr) {
+			t.Errorf("ValidateMarkdown %q err %v", tc.md, err)
+			continue
+		}
+		if mderr.Offset != tc.offset || mderr.Line != tc.line || mderr.Column != tc.column || len(mderr.Message) < len(tc.messagePrefix) || mderr.Message[:len(tc.messagePrefix)] != tc.messagePrefix {
+			t.Errorf("ValidateMarkdown %q err %#v", tc.md, mderr)
+		}
+	}
+
+}
+
+func TestHelpersMarkdown(t *testing.T) {
+
+	msg := Esc("special chars: \\*_[]()~`>#+-=|{}.!") + NL +
+		Bold("bold") + NL +
+		Italic("italic") + NL +
+		Underline("underline") + NL +
+		BoldUnderline("bold underline") + NL +
+		ItalicUnderline("italic underline") + NL +
+		Spoiler("spoiler") + NL +
+		Code("code") + NL +
+		Link("bots api link", "https://core.telegram.org/bots/api") + NL +
+		Pre("  pre * "+NL+"  * - * "+NL+"  * formatted") + NL +
+		Quote("normal"+NL+"quote"+NL+"block") + NL +
+		ExpandQuote("expandable"+NL+"quote"+NL+"block")
+
+	if err := ValidateMarkdown(msg); err != nil {
+		t.Error(err)
+	}
+
+}
@@ -0,0 +1,239 @@
+package tg
+
+import (
+	"strings"
+)
+
+// Markdown is MarkdownV2 text where plain text only gets in escaped.
+// It is built by MarkdownText, the formatting methods and MarkdownBuilder,
+// markup written by hand gets in only through RawMarkdown.
+// Text with ParseModeMarkdownV2 is sent as is.
+type Markdown struct {
+	s string
+}
+
+// RawMarkdown takes s as already valid MarkdownV2 without escaping it.
+func RawMarkdown(s string) Markdown {
+	return Markdown{s: s}
+}
+
+// MarkdownText escapes plain text.
+func MarkdownText(s string) Markdown {
+	return RawMarkdown(Esc(s))
+}
+
+func MarkdownTextf(format string, a ...interface{}) Markdown {
+	return MarkdownText(F(format, a...))
+}
+
+// JoinMarkdown concatenates fragments keeping adjacent markers apart
+// and starting quotes on a new line.
+func JoinMarkdown(mm ...Markdown) Markdown {
+	var b strings.Builder
+	for _, m := range mm {
+		if m.s == "" {
+			continue
+		}
+		s := b.String()
+		switch {
+		case strings.HasPrefix(m.s, "_") && strings.HasSuffix(s, "_"):
+			// ___ is ambiguous between italic and underline, telegram ignores \r
+			b.WriteString("\r")
+		case (strings.HasPrefix(m.s, ">") || strings.HasPrefix(m.s, "**>")) && s != "" && !strings.HasSuffix(s, NL):
+			b.WriteString(NL)
+		}
+		b.WriteString(m.s)
+	}
+	return RawMarkdown(b.String())
+}
+
+// covered reports if the marker opening m is closed only at its very end,
+// so the whole of m is already formatted with the marker.
+// The scan stops at the first closing marker and skips escapes, code and link urls.
+func (m Markdown) covered(marker string) bool {
+	s := m.s
+	n := len(marker)
+	if len(s) <= 2*n || !strings.HasPrefix(s, marker) || !strings.HasSuffix(s, marker) {
+		return false
+	}
+	// a single _ opening __ is underline, not italic
+	if marker == "_" && s[1] == '_' {
+		return false
+	}
+	for i := n; i < len(s); {
+		switch {
+		case s[i] == '\\':
+			i += 2
+		case strings.HasPrefix(s[i:], "```"):
+			i = markdownSkip(s, i+3, "```")
+		case s[i] == '`':
+			i = markdownSkip(s, i+1, "`")
+		case strings.HasPrefix(s[i:], "]("):
+			i = markdownSkip(s, i+2, ")")
+		case s[i] == '_' && (marker == "_" || marker == "__"):
+			// underline and italic markers tell apart by the next character
+			if strings.HasPrefix(s[i:], "__") {
+				if marker == "__" {
+					return i == len(s)-n
+				}
+				i += 2
+			} else {
+				if marker == "_" {
+					return i == len(s)-n
+				}
+				i++
+			}
+		case strings.HasPrefix(s[i:], marker):
+			return i == len(s)-n
+		default:
+			i++
+		}
+	}
+	return false
+}
+
+// markdownSkip returns the index after the unescaped end found from i on.
+func markdownSkip(s string, i int, end string) int {
+	for i < len(s) {
+		if s[i] == '\\' {
+			i += 2
+			continue
+		}
+		if strings.HasPrefix(s[i:], end) {
+			return i + len(end)
+		}
+		i++
+	}
+	return i
+}
+
+func (m Markdown) wrap(marker string) Markdown {
+	if m.s == "" || m.covered(marker) {
+		return m
+	}
+	return JoinMarkdown(RawMarkdown(marker), m, RawMarkdown(marker))
+}
+
+func (m Markdown) Bold() Markdown {
+	return m.wrap("*")
+}
+
+func (m Markdown) Italic() Markdown {
+	return m.wrap("_")
+}
+
+func (m Markdown) Underline() Markdown {
+	return m.wrap("__")
+}
+
+func (m Markdown) Strikethrough() Markdown {
+	return m.wrap("~")
+}
+
+func (m Markdown) Spoiler() Markdown {
+	return m.wrap("||")
+}
+
+func (m Markdown) Link(url string) Markdown {
+	return RawMarkdown("[" + m.s + "](" + escMarkdownUrl(url) + ")")
+}
+
+func (m Markdown) Quote() Markdown {
+	return RawMarkdown(Quote(strings.TrimSuffix(m.s, NL)))
+}
+
+func (m Markdown) ExpandQuote() Markdown {
+	return RawMarkdown(ExpandQuote(strings.TrimSuffix(m.s, NL)))
+}
+
+func (m Markdown) String() string {
+	return m.s
+}
+
+func MarkdownCode(s string) Markdown {
+	return RawMarkdown(Code(s))
+}
+
+func MarkdownPre(s string) Markdown {
+	return RawMarkdown(Pre(s))
+}
+
+func MarkdownPreLang(lang, s string) Markdown {
+	return RawMarkdown(PreLang(lang, s))
+}
+
+// MarkdownBuilder accumulates MarkdownV2 text escaping plain text parts.
+type MarkdownBuilder struct {
+	mm []Markdown
+}
+
+func (b *MarkdownBuilder) Text(s string) *MarkdownBuilder {
+	b.mm = append(b.mm, MarkdownText(s))
+	return b
+}
+
+func (b *MarkdownBuilder) Textf(format string, a ...interface{}) *MarkdownBuilder {
+	b.mm = append(b.mm, MarkdownTextf(format, a...))
+	return b
+}
+
+func (b *MarkdownBuilder) Add(mm ...Markdown) *MarkdownBuilder {
+	b.mm = append(b.mm, mm...)
+	return b
+}
+
+func (b *MarkdownBuilder) Bold(mm ...Markdown) *MarkdownBuilder {
+	return b.Add(JoinMarkdown(mm...).Bold())
+}
+
+func (b *MarkdownBuilder) Italic(mm ...Markdown) *MarkdownBuilder {
+	return b.Add(JoinMarkdown(mm...).Italic())
+}
+
+func (b *MarkdownBuilder) Underline(mm ...Markdown) *MarkdownBuilder {
+	return b.Add(JoinMarkdown(mm...).Underline())
+}
+
+func (b *MarkdownBuilder) Strikethrough(mm ...Markdown) *MarkdownBuilder {
+	return b.Add(JoinMarkdown(mm...).Strikethrough())
+}
+
+func (b *MarkdownBuilder) Spoiler(mm ...Markdown) *MarkdownBuilder {
+	return b.Add(JoinMarkdown(mm...).Spoiler())
+}
+
+func (b *MarkdownBuilder) Code(s string) *MarkdownBuilder {
+	return b.Add(MarkdownCode(s))
+}
+
+func (b *MarkdownBuilder) Pre(s string) *MarkdownBuilder {
+	return b.Add(MarkdownPre(s))
+}
+
+func (b *MarkdownBuilder) PreLang(lang, s string) *MarkdownBuilder {
+	return b.Add(MarkdownPreLang(lang, s))
+}
+
+func (b *MarkdownBuilder) Link(text, url string) *MarkdownBuilder {
+	return b.Add(MarkdownText(text).Link(url))
+}
+
+func (b *MarkdownBuilder) Quote(mm ...Markdown) *MarkdownBuilder {
+	return b.Add(JoinMarkdown(mm...).Quote())
+}
+
+func (b *MarkdownBuilder) ExpandQuote(mm ...Markdown) *MarkdownBuilder {
+	return b.Add(JoinMarkdown(mm...).ExpandQuote())
+}
+
+func (b *MarkdownBuilder) NL() *MarkdownBuilder {
+	return b.Add(RawMarkdown(NL))
+}
+
+func (b *MarkdownBuilder) Markdown() Markdown {
+	return JoinMarkdown(b.mm...)
+}
+
+func (b *MarkdownBuilder) String() string {
+	return b.Markdown().s
+}
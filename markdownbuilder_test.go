@@ -0,0 +1,122 @@
+package tg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarkdownBuilder(t *testing.T) {
+
+	for _, tc := range []struct {
+		m        Markdown
+		text     string
+		entities []MessageEntity
+	}{
+		{
+			m:    MarkdownText("1+1=2. (ok)!"),
+			text: "1+1=2. (ok)!",
+		},
+		{
+			m:    MarkdownText("bold").Bold().Bold(),
+			text: "bold",
+			entities: []MessageEntity{
+				{Type: EntityTypeBold, Offset: 0, Length: 4},
+			},
+		},
+		{
+			m:    MarkdownText("x").Underline().Italic(),
+			text: "x",
+			entities: []MessageEntity{
+				{Type: EntityTypeItalic, Offset: 0, Length: 1},
+				{Type: EntityTypeUnderline, Offset: 0, Length: 1},
+			},
+		},
+		{
+			m:    MarkdownText("x").Italic().Underline(),
+			text: "x",
+			entities: []MessageEntity{
+				{Type: EntityTypeItalic, Offset: 0, Length: 1},
+				{Type: EntityTypeUnderline, Offset: 0, Length: 1},
+			},
+		},
+		{
+			m:    JoinMarkdown(MarkdownText("a_b").Italic(), MarkdownText("c").Italic()),
+			text: "a_bc",
+			entities: []MessageEntity{
+				{Type: EntityTypeItalic, Offset: 0, Length: 3},
+				{Type: EntityTypeItalic, Offset: 3, Length: 1},
+			},
+		},
+		{
+			m: (&MarkdownBuilder{}).
+				Text("say: ").
+				Quote(MarkdownText("hi *there*").Bold().Italic(), RawMarkdown(NL), MarkdownCode("a`b")).
+				Link("docs [1]", "https://example.org/(x)").
+				Markdown(),
+			text: "say: \nhi *there*\na`b\ndocs [1]",
+			entities: []MessageEntity{
+				{Type: EntityTypeBlockquote, Offset: 6, Length: 14},
+				{Type: EntityTypeBold, Offset: 6, Length: 10},
+				{Type: EntityTypeItalic, Offset: 6, Length: 10},
+				{Type: EntityTypeCode, Offset: 17, Length: 3},
+				{Type: EntityTypeTextLink, Offset: 21, Length: 8, Url: "https://example.org/(x)"},
+			},
+		},
+	} {
+		text, entities, err := ParseMarkdown(tc.m.String())
+		if err != nil {
+			t.Errorf("%q: %v", tc.m, err)
+			continue
+		}
+		if text != tc.text || !reflect.DeepEqual(entities, tc.entities) {
+			t.Errorf("ParseMarkdown %q == %q %+v expected %q %+v", tc.m, text, entities, tc.text, tc.entities)
+		}
+	}
+
+}
+
+func TestMarkdownCovered(t *testing.T) {
+
+	for _, tc := range []struct {
+		m       string
+		marker  string
+		covered bool
+	}{
+		{"*bold*", "*", true},
+		{"*a* *b*", "*", false},
+		{"*a `*` [x](https://x.org/*) \\* b*", "*", true},
+		{"*a ```\n*\n``` b*", "*", true},
+		{"_\r__x__\r_", "_", true},
+		{"__x__", "_", false},
+		{"__\r_x_\r__", "__", true},
+		{"_a_ _b_", "_", false},
+		{"||a|| ||b||", "||", false},
+		{"~gone~", "~", true},
+		{"**", "*", false},
+		{"bold", "*", false},
+	} {
+		if covered := RawMarkdown(tc.m).covered(tc.marker); covered != tc.covered {
+			t.Errorf("covered %q %q == %v expected %v", tc.m, tc.marker, covered, tc.covered)
+		}
+	}
+
+}
+
+func TestRawMarkdown(t *testing.T) {
+
+	// a string does not convert to Markdown, neither of these compiles:
+	//	Markdown("1.5")
+	//	SendMessageRequest{Markdown: "1.5"}
+	// unescaped text gets in only through MarkdownText and markup only through RawMarkdown
+
+	if m := MarkdownText("1.5"); m.String() != "1\\.5" {
+		t.Errorf("MarkdownText %q expected %q", m, "1\\.5")
+	}
+	if m := RawMarkdown("*1\\.5*"); m.String() != "*1\\.5*" || m != MarkdownText("1.5").Bold() {
+		t.Errorf("RawMarkdown %q", m)
+	}
+	if m := (Markdown{}); m.String() != "" || JoinMarkdown(m, MarkdownText("a")) != MarkdownText("a") {
+		t.Errorf("zero Markdown %q", m)
+	}
+
+}
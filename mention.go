@@ -0,0 +1,71 @@
+package tg
+
+import (
+	"strings"
+)
+
+// DisplayName returns the full name of the user falling back to the username and the id.
+func (u *User) DisplayName() string {
+	if name := strings.TrimSpace(u.FirstName + SP + u.LastName); name != "" {
+		return name
+	}
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	return F("%d", u.Id)
+}
+
+// DisplayName returns the title of the chat or the name of the private chat user
+// falling back to the username and the id.
+func (c *Chat) DisplayName() string {
+	if c.Title != "" {
+		return c.Title
+	}
+	if name := strings.TrimSpace(c.FirstName + SP + c.LastName); name != "" {
+		return name
+	}
+	if c.Username != "" {
+		return "@" + c.Username
+	}
+	return F("%d", c.Id)
+}
+
+func TextMention(text string, userid int64) string {
+	// https://core.telegram.org/bots/api#markdownv2-style
+	return F("[%s](tg://user?id=%d)", Esc(text), userid)
+}
+
+// Mention links the user display name to the user so that it works without a username.
+func Mention(u User) string {
+	return TextMention(u.DisplayName(), u.Id)
+}
+
+func HtmlMention(u User) Html {
+	return HtmlTextMention(HtmlText(u.DisplayName()), u.Id)
+}
+
+// CustomEmoji shows the custom emoji or the fallback emoji where custom emoji are not available.
+func CustomEmoji(id, fallback string) string {
+	// https://core.telegram.org/bots/api#markdownv2-style
+	return "![" + Esc(fallback) + "](tg://emoji?id=" + escMarkdownUrl(id) + ")"
+}
+
+func (b *MarkdownBuilder) Mention(u User) *MarkdownBuilder {
+	return b.Add(RawMarkdown(Mention(u)))
+}
+
+func (b *MarkdownBuilder) CustomEmoji(id, fallback string) *MarkdownBuilder {
+	return b.Add(RawMarkdown(CustomEmoji(id, fallback)))
+}
+
+func (b *TextBuilder) TextMention(s string, u User) *TextBuilder {
+	return b.Nest(MessageEntity{Type: EntityTypeTextMention, User: &User{Id: u.Id}}, func(b *TextBuilder) { b.Text(s) })
+}
+
+func (b *TextBuilder) Mention(u User) *TextBuilder {
+	return b.TextMention(u.DisplayName(), u)
+}
+
+func (b *TextBuilder) CustomEmoji(id, fallback string) *TextBuilder {
+	return b.Nest(MessageEntity{Type: EntityTypeCustomEmoji, CustomEmojiId: id}, func(b *TextBuilder) { b.Text(fallback) })
+}
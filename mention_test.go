@@ -0,0 +1,73 @@
+package tg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDisplayName(t *testing.T) {
+
+	for _, tc := range []struct {
+		u    User
+		name string
+	}{
+		{User{Id: 1, FirstName: "Ann", LastName: "Lee", Username: "ann"}, "Ann Lee"},
+		{User{Id: 1, FirstName: "Ann"}, "Ann"},
+		{User{Id: 1, Username: "ann"}, "@ann"},
+		{User{Id: 1}, "1"},
+	} {
+		if name := tc.u.DisplayName(); name != tc.name {
+			t.Errorf("DisplayName %+v == %q expected %q", tc.u, name, tc.name)
+		}
+	}
+
+	for _, tc := range []struct {
+		c    Chat
+		name string
+	}{
+		{Chat{Id: -100, Type: "supergroup", Title: "Ops [prod]"}, "Ops [prod]"},
+		{Chat{Id: 1, Type: "private", FirstName: "Ann", LastName: "Lee"}, "Ann Lee"},
+		{Chat{Id: -100, Type: "channel", Username: "news"}, "@news"},
+	} {
+		if name := tc.c.DisplayName(); name != tc.name {
+			t.Errorf("DisplayName %+v == %q expected %q", tc.c, name, tc.name)
+		}
+	}
+
+}
+
+func TestMention(t *testing.T) {
+
+	u := User{Id: 42, FirstName: "J.R.", LastName: "[admin]"}
+	emojiid := "5368324170671202286"
+
+	m := Mention(u) + " " + CustomEmoji(emojiid, "👍")
+	if m != "[J\\.R\\. \\[admin\\]](tg://user?id=42) ![👍](tg://emoji?id=5368324170671202286)" {
+		t.Errorf("markdown %q", m)
+	}
+	if h := HtmlMention(u); h != `<a href="tg://user?id=42">J.R. [admin]</a>` {
+		t.Errorf("html %q", h)
+	}
+
+	var b TextBuilder
+	b.Mention(u).Text(" ").CustomEmoji(emojiid, "👍")
+	entities := []MessageEntity{
+		{Type: EntityTypeTextMention, Offset: 0, Length: 12, User: &User{Id: 42}},
+		{Type: EntityTypeCustomEmoji, Offset: 13, Length: 2, CustomEmojiId: emojiid},
+	}
+	if !reflect.DeepEqual(b.Entities(), entities) {
+		t.Errorf("entities %+v", b.Entities())
+	}
+
+	text, mdentities, err := ParseMarkdown(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != b.String() || !reflect.DeepEqual(mdentities, entities) {
+		t.Errorf("ParseMarkdown %q %+v", text, mdentities)
+	}
+	if h := Html(EntitiesHTML(b.String(), b.Entities())); h != HtmlMention(u)+" "+HtmlCustomEmoji(emojiid, "👍") {
+		t.Errorf("EntitiesHTML %q", h)
+	}
+
+}
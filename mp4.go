@@ -0,0 +1,208 @@
+package tg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// https://developer.apple.com/documentation/quicktime-file-format
+// https://www.iso.org/standard/83102.html
+
+var (
+	Mp4MoovMaxSize int64 = 64 << 20
+)
+
+type Mp4Info struct {
+	Width, Height int
+	Duration      time.Duration
+	// moov box goes before mdat box so the video can be played while downloading
+	SupportsStreaming bool
+}
+
+type mp4box struct {
+	typ  string
+	data []byte
+}
+
+// mp4boxes splits data into the boxes it contains.
+func mp4boxes(data []byte) (bb []mp4box) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		typ := string(data[4:8])
+		hsize := uint64(8)
+		if size == 1 {
+			if len(data) < 16 {
+				return bb
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			hsize = 16
+		} else if size == 0 {
+			size = uint64(len(data))
+		}
+		if size < hsize || size > uint64(len(data)) {
+			return bb
+		}
+		bb = append(bb, mp4box{typ: typ, data: data[hsize:size]})
+		data = data[size:]
+	}
+	return bb
+}
+
+func mp4child(data []byte, path ...string) []byte {
+	for _, typ := range path {
+		found := false
+		for _, b := range mp4boxes(data) {
+			if b.typ == typ {
+				data, found = b.data, true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return data
+}
+
+// mp4readmoov finds the top level moov box and returns its payload.
+// streaming reports if moov goes before the first mdat box.
+func mp4readmoov(r io.ReadSeeker) (moov []byte, streaming bool, err error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, false, fmt.Errorf("Seek %w", err)
+	}
+
+	pos := start
+	mdatseen := false
+	hbuf := make([]byte, 16)
+	for {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return nil, false, fmt.Errorf("Seek %w", err)
+		}
+		if _, err := io.ReadFull(r, hbuf[:8]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, false, fmt.Errorf("moov box not found")
+			}
+			return nil, false, fmt.Errorf("Read %w", err)
+		}
+		size := int64(binary.BigEndian.Uint32(hbuf[0:4]))
+		typ := string(hbuf[4:8])
+		hsize := int64(8)
+		if size == 1 {
+			if _, err := io.ReadFull(r, hbuf[8:16]); err != nil {
+				return nil, false, fmt.Errorf("Read %w", err)
+			}
+			size = int64(binary.BigEndian.Uint64(hbuf[8:16]))
+			hsize = 16
+		} else if size == 0 {
+			end, err := r.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, false, fmt.Errorf("Seek %w", err)
+			}
+			size = end - pos
+		}
+		if size < hsize {
+			return nil, false, fmt.Errorf("invalid %q box size <%d> at offset <%d>", typ, size, pos-start)
+		}
+
+		switch typ {
+		case "mdat":
+			mdatseen = true
+		case "moov":
+			if size-hsize > Mp4MoovMaxSize {
+				return nil, false, fmt.Errorf("moov box size <%d> is too big", size-hsize)
+			}
+			if _, err := r.Seek(pos+hsize, io.SeekStart); err != nil {
+				return nil, false, fmt.Errorf("Seek %w", err)
+			}
+			moov = make([]byte, size-hsize)
+			if _, err := io.ReadFull(r, moov); err != nil {
+				return nil, false, fmt.Errorf("Read moov %w", err)
+			}
+			return moov, !mdatseen, nil
+		}
+
+		pos += size
+	}
+}
+
+func mp4duration(data []byte) time.Duration {
+	// mvhd and mdhd share the layout of version, timestamps, timescale and duration
+	var timescale, duration uint64
+	if len(data) >= 32 && data[0] == 1 {
+		timescale, duration = uint64(binary.BigEndian.Uint32(data[20:24])), binary.BigEndian.Uint64(data[24:32])
+	} else if len(data) >= 20 && data[0] == 0 {
+		timescale, duration = uint64(binary.BigEndian.Uint32(data[12:16])), uint64(binary.BigEndian.Uint32(data[16:20]))
+	}
+	if timescale == 0 {
+		return 0
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+}
+
+func mp4tkhdsize(data []byte) (width, height int) {
+	matrix := 40
+	if len(data) > 0 && data[0] == 1 {
+		matrix = 52
+	}
+	if len(data) < matrix+44 {
+		return 0, 0
+	}
+	width = int(binary.BigEndian.Uint32(data[matrix+36:matrix+40]) >> 16)
+	height = int(binary.BigEndian.Uint32(data[matrix+40:matrix+44]) >> 16)
+	// rotation by 90 or 270 degrees has zero a and d matrix components
+	a := binary.BigEndian.Uint32(data[matrix : matrix+4])
+	d := binary.BigEndian.Uint32(data[matrix+16 : matrix+20])
+	if a == 0 && d == 0 {
+		width, height = height, width
+	}
+	return width, height
+}
+
+func mp4stsdsize(stsd []byte) (width, height int) {
+	// version and flags, entry count, then the first visual sample entry
+	if len(stsd) < 8+8+28 {
+		return 0, 0
+	}
+	entry := stsd[8+8:]
+	return int(binary.BigEndian.Uint16(entry[24:26])), int(binary.BigEndian.Uint16(entry[26:28]))
+}
+
+// ProbeMp4 reads width, height and duration of the first video track from the moov box of an MP4 or MOV file.
+// The reader is left at an unspecified position.
+func ProbeMp4(r io.ReadSeeker) (info Mp4Info, err error) {
+	moov, streaming, err := mp4readmoov(r)
+	if err != nil {
+		return Mp4Info{}, err
+	}
+	info.SupportsStreaming = streaming
+
+	info.Duration = mp4duration(mp4child(moov, "mvhd"))
+
+	for _, trak := range mp4boxes(moov) {
+		if trak.typ != "trak" {
+			continue
+		}
+		hdlr := mp4child(trak.data, "mdia", "hdlr")
+		if len(hdlr) < 12 || !bytes.Equal(hdlr[8:12], []byte("vide")) {
+			continue
+		}
+		info.Width, info.Height = mp4tkhdsize(mp4child(trak.data, "tkhd"))
+		if info.Width == 0 || info.Height == 0 {
+			info.Width, info.Height = mp4stsdsize(mp4child(trak.data, "mdia", "minf", "stbl", "stsd"))
+		}
+		if info.Duration == 0 {
+			info.Duration = mp4duration(mp4child(trak.data, "mdia", "mdhd"))
+		}
+		break
+	}
+
+	if info.Width == 0 || info.Height == 0 {
+		return info, fmt.Errorf("video track not found")
+	}
+
+	return info, nil
+}
@@ -0,0 +1,96 @@
+package tg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMp4Box(typ string, payloads ...[]byte) []byte {
+	var data []byte
+	for _, p := range payloads {
+		data = append(data, p...)
+	}
+	b := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint32(b[0:4], uint32(8+len(data)))
+	copy(b[4:8], typ)
+	return append(b, data...)
+}
+
+func testMp4(mdatfirst bool) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:16], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:20], 5500)
+
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[40:44], 1<<16)
+	binary.BigEndian.PutUint32(tkhd[56:60], 1<<16)
+	binary.BigEndian.PutUint32(tkhd[76:80], 640<<16)
+	binary.BigEndian.PutUint32(tkhd[80:84], 360<<16)
+
+	hdlr := make([]byte, 24)
+	copy(hdlr[8:12], "vide")
+
+	moov := testMp4Box("moov",
+		testMp4Box("mvhd", mvhd),
+		testMp4Box("trak",
+			testMp4Box("tkhd", tkhd),
+			testMp4Box("mdia", testMp4Box("hdlr", hdlr)),
+		),
+	)
+	ftyp := testMp4Box("ftyp", []byte("isom\x00\x00\x02\x00"))
+	mdat := testMp4Box("mdat", make([]byte, 1000))
+
+	if mdatfirst {
+		return bytes.Join([][]byte{ftyp, mdat, moov}, nil)
+	}
+	return bytes.Join([][]byte{ftyp, moov, mdat}, nil)
+}
+
+func TestProbeMp4(t *testing.T) {
+
+	for _, mdatfirst := range []bool{false, true} {
+		info, err := ProbeMp4(bytes.NewReader(testMp4(mdatfirst)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Width != 640 || info.Height != 360 || info.Duration != 5500*time.Millisecond {
+			t.Errorf("info %#v", info)
+		}
+		if info.SupportsStreaming == mdatfirst {
+			t.Errorf("mdatfirst==%v info.SupportsStreaming==%v", mdatfirst, info.SupportsStreaming)
+		}
+	}
+
+	if _, err := ProbeMp4(bytes.NewReader([]byte("not a video file at all"))); err == nil {
+		t.Errorf("expected error for invalid input")
+	}
+
+}
+
+func TestSendVideoFileProbe(t *testing.T) {
+
+	var width, duration string
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		width, duration = r.FormValue("width"), r.FormValue("duration")
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"video":{"file_id":"video"}}}`))
+	})
+
+	for _, probe := range []bool{false, true} {
+		if _, err := SendVideoFile(SendVideoFileRequest{ChatId: "1", Video: bytes.NewReader(testMp4(false)), Probe: probe}); err != nil {
+			t.Fatal(err)
+		}
+		if probe && (width != "640" || duration != "5") || !probe && (width != "0" || duration != "0") {
+			t.Errorf("Probe==%v width==%q duration==%q", probe, width, duration)
+		}
+	}
+
+	if _, err := SendVideoFile(SendVideoFileRequest{ChatId: "1", Video: io.LimitReader(strings.NewReader("mp4"), 3), Probe: true}); err == nil {
+		t.Errorf("expected error for Probe without io.ReadSeeker")
+	}
+
+}
@@ -0,0 +1,107 @@
+package tg
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// StripAnsi removes ANSI escape sequences and other control characters from terminal output.
+// Carriage returns and backspaces are applied the way a terminal shows them.
+func StripAnsi(s string) string {
+	// https://en.wikipedia.org/wiki/ANSI_escape_code
+	// https://invisible-island.net/xterm/ctlseqs/ctlseqs.html
+	var lines []string
+	var line []rune
+	// cursor position in the line for carriage returns
+	var col int
+	put := func(r rune) {
+		if col < len(line) {
+			line[col] = r
+		} else {
+			line = append(line, r)
+		}
+		col++
+	}
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+
+		switch {
+		case r == '\x1b' && i < len(s):
+			switch s[i] {
+			case '[':
+				// CSI parameters and intermediates up to the final byte
+				for i++; i < len(s) && (s[i] < 0x40 || s[i] > 0x7e); i++ {
+				}
+				i++
+			case ']', 'P', '_', '^', 'X':
+				// OSC and other strings up to BEL or ST
+				for i++; i < len(s); i++ {
+					if s[i] == '\a' {
+						i++
+						break
+					}
+					if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+						i += 2
+						break
+					}
+				}
+			case '(', ')', '*', '+', '#', '%':
+				// character set designation with one more byte
+				i += 2
+			default:
+				i++
+			}
+		case r == '\u009b':
+			for ; i < len(s) && (s[i] < 0x40 || s[i] > 0x7e); i++ {
+			}
+			i++
+		case r == '\n':
+			lines = append(lines, string(line))
+			line, col = line[:0], 0
+		case r == '\r':
+			col = 0
+		case r == '\b':
+			col = max(col-1, 0)
+		case r == '\t':
+			put(r)
+		case r < 0x20 || r == 0x7f || r >= 0x80 && r < 0xa0:
+		default:
+			put(r)
+		}
+	}
+	lines = append(lines, string(line))
+
+	return strings.Join(lines, NL)
+}
+
+// PreOutput embeds command output as a pre block stripped of ANSI escape sequences.
+// When the output text is longer than maxlen UTF-16 code units
+// its beginning is cut at a line boundary, the end of output is usually more interesting.
+func PreOutput(output string, maxlen int) string {
+	if maxlen <= 0 {
+		maxlen = MessageTextMaxLen
+	}
+	output = strings.TrimRight(StripAnsi(output), NL)
+
+	if Utf16Len(output) > maxlen {
+		const cut = "…" + NL
+		rs := []rune(output)
+		start, n := len(rs), Utf16Len(cut)
+		for start > 0 && n+utf16len(rs[start-1]) <= maxlen {
+			start--
+			n += utf16len(rs[start])
+		}
+		tail := string(rs[start:])
+		// drop the first line unless the cut is right after a newline
+		if rs[start-1] != '\n' {
+			if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+				tail = tail[i+1:]
+			}
+		}
+		output = cut + tail
+	}
+
+	return Pre(output)
+}
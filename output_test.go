@@ -0,0 +1,88 @@
+package tg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreLang(t *testing.T) {
+
+	for _, tc := range []struct {
+		lang, text string
+	}{
+		{"go", "fmt.Println(\"```\")"},
+		{"c++", "a \\ b `c`"},
+		{"bad lang`", "x"},
+		{"", "\\```\\"},
+	} {
+		m := PreLang(tc.lang, tc.text)
+		text, entities, err := ParseMarkdown(m)
+		if err != nil {
+			t.Errorf("%q: %v", m, err)
+			continue
+		}
+		lang := strings.NewReplacer(" ", "", "`", "").Replace(tc.lang)
+		if text != tc.text || len(entities) != 1 || entities[0].Type != EntityTypePre || entities[0].Language != lang {
+			t.Errorf("ParseMarkdown %q == %q %+v", m, text, entities)
+		}
+	}
+
+	for _, s := range []string{"a`b", "\\", "``` `", "x\\`y"} {
+		text, _, err := ParseMarkdown(Code(s))
+		if err != nil || text != s {
+			t.Errorf("ParseMarkdown Code %q == %q %v", s, text, err)
+		}
+	}
+
+}
+
+func TestStripAnsi(t *testing.T) {
+
+	for _, tc := range []struct {
+		in, out string
+	}{
+		{"\x1b[1;31mERROR\x1b[0m done", "ERROR done"},
+		{"\x1b]0;title\a\x1b]8;;http://x\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"\x1b(Bok\x1b=", "ok"},
+		{"10%\r50%\r100%\nnext", "100%\nnext"},
+		{"abc\b\bX", "aXc"},
+		{"a\tb\x00\x7f", "a\tb"},
+		{"line\r\nline", "line\nline"},
+	} {
+		if out := StripAnsi(tc.in); out != tc.out {
+			t.Errorf("StripAnsi %q == %q expected %q", tc.in, out, tc.out)
+		}
+	}
+
+}
+
+func TestPreOutput(t *testing.T) {
+
+	if m := PreOutput("\x1b[32mok\x1b[0m `x`\n\n", 0); m != "```\nok \\`x\\`\n```" {
+		t.Errorf("PreOutput %q", m)
+	}
+
+	var lines []string
+	for i := 0; i < 1000; i++ {
+		lines = append(lines, F("line %03d 👍", i))
+	}
+	m := PreOutput(strings.Join(lines, NL), 100)
+	text, _, err := ParseMarkdown(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Utf16Len(text) > 100 || !strings.HasPrefix(text, "…\nline ") || !strings.HasSuffix(text, "line 999 👍") {
+		t.Errorf("PreOutput %d %q", Utf16Len(text), text)
+	}
+
+	// the cut right after a newline keeps the whole next line
+	m = PreOutput("aaaa\nbbbb\ncccc", 11)
+	if text, _, err := ParseMarkdown(m); err != nil || text != "…\nbbbb\ncccc" {
+		t.Errorf("PreOutput %q expected %q err %v", text, "…\nbbbb\ncccc", err)
+	}
+	m = PreOutput("aaaa\nbbbb\ncccc", 10)
+	if text, _, err := ParseMarkdown(m); err != nil || text != "…\ncccc" {
+		t.Errorf("PreOutput %q expected %q err %v", text, "…\ncccc", err)
+	}
+
+}
@@ -0,0 +1,229 @@
+package tg
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"strings"
+)
+
+// https://core.telegram.org/bots/api#sendphoto
+
+var (
+	PhotoMaxSize        int64 = 10 << 20
+	PhotoMaxWidthHeight       = 10000
+	PhotoMaxAspectRatio       = 20
+
+	PhotoJpegQuality = 90
+)
+
+// PhotoError reports the photo limits violated or that the photo could not be decoded.
+type PhotoError struct {
+	Size          int64
+	Width, Height int
+
+	DecodeErr error
+
+	SizeExceeded        bool
+	WidthHeightExceeded bool
+	AspectRatioExceeded bool
+}
+
+func (e *PhotoError) Error() string {
+	var ss []string
+	if e.DecodeErr != nil {
+		ss = append(ss, F("decode %v", e.DecodeErr))
+	}
+	if e.SizeExceeded {
+		ss = append(ss, F("size <%d> exceeds <%d>", e.Size, PhotoMaxSize))
+	}
+	if e.WidthHeightExceeded {
+		ss = append(ss, F("width+height <%d+%d> exceeds <%d>", e.Width, e.Height, PhotoMaxWidthHeight))
+	}
+	if e.AspectRatioExceeded {
+		ss = append(ss, F("aspect ratio of <%dx%d> exceeds <%d>", e.Width, e.Height, PhotoMaxAspectRatio))
+	}
+	return "photo " + strings.Join(ss, ", ")
+}
+
+func (e *PhotoError) Unwrap() error {
+	return e.DecodeErr
+}
+
+// CheckPhoto verifies the photo against the sendPhoto limits.
+// It returns a *PhotoError if any limit is exceeded or the photo is not a JPEG, PNG or GIF image.
+func CheckPhoto(data []byte) (cfg image.Config, format string, err error) {
+	cfg, format, err = image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return cfg, "", &PhotoError{Size: int64(len(data)), DecodeErr: err}
+	}
+
+	e := &PhotoError{Size: int64(len(data)), Width: cfg.Width, Height: cfg.Height}
+	e.SizeExceeded = e.Size > PhotoMaxSize
+	e.WidthHeightExceeded = cfg.Width+cfg.Height > PhotoMaxWidthHeight
+	long, short := cfg.Width, cfg.Height
+	if long < short {
+		long, short = short, long
+	}
+	e.AspectRatioExceeded = short == 0 || long > short*PhotoMaxAspectRatio
+
+	if e.SizeExceeded || e.WidthHeightExceeded || e.AspectRatioExceeded {
+		return cfg, format, e
+	}
+
+	return cfg, format, nil
+}
+
+// DownscalePhoto re-encodes the photo as JPEG scaled down to fit the size and width+height limits.
+// It cannot fix the aspect ratio.
+func DownscalePhoto(data []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image.Decode %w", err)
+	}
+
+	b := img.Bounds()
+	scale := 1.0
+	if wh := b.Dx() + b.Dy(); wh > PhotoMaxWidthHeight {
+		scale = float64(PhotoMaxWidthHeight) / float64(wh)
+	}
+
+	for {
+		w, h := int(float64(b.Dx())*scale), int(float64(b.Dy())*scale)
+		if w < 1 || h < 1 {
+			return nil, fmt.Errorf("photo cannot be downscaled to fit the limits")
+		}
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, scaleImage(img, w, h), &jpeg.Options{Quality: PhotoJpegQuality}); err != nil {
+			return nil, fmt.Errorf("jpeg.Encode %w", err)
+		}
+		if int64(buf.Len()) <= PhotoMaxSize {
+			perr(F("DEBUG DownscalePhoto <%dx%d> size <%d> to <%dx%d> size <%d>", b.Dx(), b.Dy(), len(data), w, h, buf.Len()))
+			return buf.Bytes(), nil
+		}
+
+		scale *= 0.75
+	}
+}
+
+// scaleImage draws img over white background and scales it to w x h
+// averaging the source pixels covered by every destination pixel.
+func scaleImage(img image.Image, w, h int) *image.RGBA {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for dy := 0; dy < h; dy++ {
+		sy0, sy1 := dy*sh/h, (dy+1)*sh/h
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+		for dx := 0; dx < w; dx++ {
+			sx0, sx1 := dx*sw/w, (dx+1)*sw/w
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+			var r, g, bl, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					// alpha premultiplied colors over white
+					cr, cg, cb, ca := img.At(b.Min.X+sx, b.Min.Y+sy).RGBA()
+					r += uint64(cr + 0xffff - ca)
+					g += uint64(cg + 0xffff - ca)
+					bl += uint64(cb + 0xffff - ca)
+					n++
+				}
+			}
+			j := dst.PixOffset(dx, dy)
+			dst.Pix[j] = uint8(r / n >> 8)
+			dst.Pix[j+1] = uint8(g / n >> 8)
+			dst.Pix[j+2] = uint8(bl / n >> 8)
+			dst.Pix[j+3] = 0xff
+		}
+	}
+
+	return dst
+}
+
+// https://core.telegram.org/bots/api#sending-files
+
+var (
+	ThumbnailMaxSize int64 = 200 << 10
+	ThumbnailMaxSide       = 320
+)
+
+// Thumbnail converts an image to a JPEG of at most ThumbnailMaxSide pixels wide and tall
+// and at most ThumbnailMaxSize bytes, as required for the thumbnail of uploaded files.
+// A JPEG already within the limits is returned as is.
+func Thumbnail(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("ReadAll %w", err)
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image.DecodeConfig %w", err)
+	}
+	if format == "jpeg" && cfg.Width <= ThumbnailMaxSide && cfg.Height <= ThumbnailMaxSide && int64(len(data)) <= ThumbnailMaxSize {
+		return data, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image.Decode %w", err)
+	}
+
+	b := img.Bounds()
+	side := ThumbnailMaxSide
+	for side > 0 {
+		w, h := b.Dx(), b.Dy()
+		if w > side || h > side {
+			if w >= h {
+				w, h = side, h*side/w
+			} else {
+				w, h = w*side/h, side
+			}
+		}
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+		thumb := scaleImage(img, w, h)
+
+		for quality := PhotoJpegQuality; quality >= 30; quality -= 15 {
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: quality}); err != nil {
+				return nil, fmt.Errorf("jpeg.Encode %w", err)
+			}
+			if int64(buf.Len()) <= ThumbnailMaxSize {
+				return buf.Bytes(), nil
+			}
+		}
+
+		side = side * 3 / 4
+	}
+
+	return nil, fmt.Errorf("image cannot be made a thumbnail")
+}
+
+// thumbnail returns the conforming thumbnail of r or the original data if r is not a decodable image.
+func thumbnail(r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	thumb, err := Thumbnail(bytes.NewReader(data))
+	if err != nil {
+		perr(F("DEBUG thumbnail %v", err))
+		return bytes.NewReader(data), nil
+	}
+	return bytes.NewReader(thumb), nil
+}
@@ -0,0 +1,137 @@
+package tg
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func testPng(t *testing.T, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x), uint8(y), 0x80, 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCheckPhoto(t *testing.T) {
+
+	defer func(maxwh int) { PhotoMaxWidthHeight = maxwh }(PhotoMaxWidthHeight)
+	PhotoMaxWidthHeight = 300
+
+	if _, format, err := CheckPhoto(testPng(t, 200, 100)); err != nil || format != "png" {
+		t.Fatalf("format %q err %v", format, err)
+	}
+
+	var photoerr *PhotoError
+
+	_, _, err := CheckPhoto(testPng(t, 250, 100))
+	if !errors.As(err, &photoerr) || !photoerr.WidthHeightExceeded || photoerr.AspectRatioExceeded || photoerr.SizeExceeded {
+		t.Fatalf("err %#v", err)
+	}
+
+	_, _, err = CheckPhoto(testPng(t, 210, 10))
+	if !errors.As(err, &photoerr) || !photoerr.AspectRatioExceeded {
+		t.Fatalf("err %#v", err)
+	}
+
+	photo, err := DownscalePhoto(testPng(t, 250, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, format, err := CheckPhoto(photo)
+	if err != nil || format != "jpeg" {
+		t.Fatalf("format %q err %v", format, err)
+	}
+	if cfg.Width+cfg.Height > PhotoMaxWidthHeight {
+		t.Errorf("downscaled photo %dx%d", cfg.Width, cfg.Height)
+	}
+
+}
+
+func TestThumbnail(t *testing.T) {
+
+	thumb, err := Thumbnail(bytes.NewReader(testPng(t, 640, 400)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil || format != "jpeg" {
+		t.Fatalf("format %q err %v", format, err)
+	}
+	if cfg.Width != 320 || cfg.Height != 200 || int64(len(thumb)) > ThumbnailMaxSize {
+		t.Errorf("thumbnail %dx%d size <%d>", cfg.Width, cfg.Height, len(thumb))
+	}
+
+	if thumb2, err := Thumbnail(bytes.NewReader(thumb)); err != nil || !bytes.Equal(thumb, thumb2) {
+		t.Errorf("conforming thumbnail was re-encoded err %v", err)
+	}
+
+}
+
+func TestSendPhotoFileCheck(t *testing.T) {
+
+	var uploads int
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		uploads++
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"photo":[{"file_id":"photo"}]}}`))
+	})
+
+	defer func(maxwh int) { PhotoMaxWidthHeight = maxwh }(PhotoMaxWidthHeight)
+	PhotoMaxWidthHeight = 300
+
+	photo := testPng(t, 250, 100)
+	if _, err := SendPhotoFile(SendPhotoFileRequest{ChatId: "1", Photo: bytes.NewReader(photo)}); err != nil {
+		t.Fatal(err)
+	}
+	var photoerr *PhotoError
+	if _, err := SendPhotoFile(SendPhotoFileRequest{ChatId: "1", Photo: bytes.NewReader(photo), Check: true}); !errors.As(err, &photoerr) {
+		t.Errorf("Check err %v", err)
+	}
+	if _, err := SendPhotoFile(SendPhotoFileRequest{ChatId: "1", Photo: bytes.NewReader(photo), Downscale: true}); err != nil {
+		t.Fatal(err)
+	}
+	// not decodable, e.g. webp
+	if _, err := SendPhotoFile(SendPhotoFileRequest{ChatId: "1", Photo: strings.NewReader("RIFF....WEBPVP8 "), Check: true}); !errors.As(err, &photoerr) || photoerr.DecodeErr == nil {
+		t.Errorf("Check undecodable err %v", err)
+	}
+	var gifbuf bytes.Buffer
+	if err := gif.Encode(&gifbuf, image.NewPaletted(image.Rect(0, 0, 20, 10), color.Palette{color.White}), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SendPhotoFile(SendPhotoFileRequest{ChatId: "1", Photo: &gifbuf, Check: true}); err != nil {
+		t.Errorf("Check gif err %v", err)
+	}
+	if uploads != 3 {
+		t.Errorf("uploads <%d> expected 3", uploads)
+	}
+
+}
+
+func TestScaleImage(t *testing.T) {
+
+	img := image.NewNRGBA(image.Rect(10, 10, 14, 12))
+	img.Set(10, 10, color.NRGBA{0, 0, 0, 0xff})
+	img.Set(11, 10, color.NRGBA{0, 0, 0, 0xff})
+
+	dst := scaleImage(img, 2, 1)
+	if c := dst.RGBAAt(0, 0); c.R != 0x7f || c.A != 0xff {
+		t.Errorf("half black half transparent pixel %v expected grey", c)
+	}
+	if c := dst.RGBAAt(1, 0); c != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("transparent pixel %v expected white", c)
+	}
+
+}
@@ -0,0 +1,132 @@
+package tg
+
+import (
+	"strings"
+)
+
+// PreviewCSS styles the html of PreviewHTML after the telegram light theme.
+const PreviewCSS = `body { background: #99ba92; margin: 0; padding: 12px; font: 15px/1.4 -apple-system, "Segoe UI", Roboto, Helvetica, Arial, sans-serif; }
+.tg-message { background: #fff; color: #000; border-radius: 12px; max-width: 460px; margin: 0 0 8px; padding: 6px 10px; white-space: pre-wrap; overflow-wrap: break-word; box-shadow: 0 1px 2px rgba(0, 0, 0, .15); }
+.tg-message a { color: #168acd; text-decoration: none; }
+.tg-message code, .tg-message pre { font-family: Menlo, Consolas, "Roboto Mono", monospace; font-size: 13px; }
+.tg-message code { color: #2a7ab8; }
+.tg-pre { display: block; margin: 4px 0; padding: 6px 8px; background: #eef4fa; border-left: 3px solid #3a8bd6; border-radius: 6px; white-space: pre-wrap; }
+.tg-pre-lang { display: block; color: #3a8bd6; font-weight: bold; font-family: inherit; }
+.tg-quote { margin: 4px 0; padding: 2px 8px; background: #e9f2fb; border-left: 3px solid #3a8bd6; border-radius: 4px; }
+.tg-expandable { max-height: 4.2em; overflow: hidden; cursor: pointer; }
+.tg-expandable.tg-expanded { max-height: none; }
+.tg-spoiler { background: #c8c8c8; color: transparent; border-radius: 4px; cursor: pointer; }
+.tg-spoiler a, .tg-spoiler code { color: transparent; }
+.tg-spoiler.tg-revealed, .tg-spoiler.tg-revealed a, .tg-spoiler.tg-revealed code { background: none; color: inherit; }
+.tg-emoji { border-bottom: 1px dotted #999; }
+`
+
+// previewWriter renders entities as html approximating the telegram look.
+type previewWriter struct {
+	htmlWriter
+	// the block just closed swallows the following newline
+	blockend bool
+}
+
+func (w *previewWriter) block() {
+	// the block starts on its own line anyway
+	if s := w.b.String(); strings.HasSuffix(s, NL) {
+		w.b.Reset()
+		w.b.WriteString(strings.TrimSuffix(s, NL))
+	}
+}
+
+func (w *previewWriter) open(e MessageEntity) {
+	w.blockend = false
+	switch e.Type {
+	case EntityTypeSpoiler:
+		w.b.WriteString(`<span class="tg-spoiler" onclick="this.classList.add('tg-revealed')">`)
+	case EntityTypeCode:
+		w.b.WriteString(`<code>`)
+	case EntityTypePre:
+		w.block()
+		w.b.WriteString(`<pre class="tg-pre">`)
+		if e.Language != "" {
+			w.b.WriteString(`<span class="tg-pre-lang">` + HtmlEsc(e.Language) + `</span>`)
+		}
+		w.b.WriteString(`<code>`)
+	case EntityTypeTextMention:
+		w.htmlWriter.open(e)
+	case EntityTypeCustomEmoji:
+		w.b.WriteString(`<span class="tg-emoji" title="custom emoji ` + HtmlEsc(e.CustomEmojiId) + `">`)
+	case EntityTypeBlockquote:
+		w.block()
+		w.b.WriteString(`<blockquote class="tg-quote">`)
+	case EntityTypeExpandableBlockquote:
+		w.block()
+		w.b.WriteString(`<blockquote class="tg-quote tg-expandable" onclick="this.classList.toggle('tg-expanded')">`)
+	default:
+		w.htmlWriter.open(e)
+	}
+}
+
+func (w *previewWriter) close(e MessageEntity) {
+	switch e.Type {
+	case EntityTypeSpoiler, EntityTypeCustomEmoji:
+		w.b.WriteString(`</span>`)
+	case EntityTypeCode:
+		w.b.WriteString(`</code>`)
+	case EntityTypePre:
+		w.b.WriteString(`</code></pre>`)
+		w.blockend = true
+	case EntityTypeBlockquote, EntityTypeExpandableBlockquote:
+		w.b.WriteString(`</blockquote>`)
+		w.blockend = true
+	default:
+		w.htmlWriter.close(e)
+	}
+}
+
+func (w *previewWriter) text(s string, code bool) {
+	if w.blockend {
+		s = strings.TrimPrefix(s, NL)
+		w.blockend = false
+	}
+	w.htmlWriter.text(s, code)
+}
+
+// PreviewEntitiesHTML renders text with entities as an html message bubble styled by PreviewCSS.
+func PreviewEntitiesHTML(text string, entities []MessageEntity) string {
+	return `<div class="tg-message">` + renderEntities(text, entities, &previewWriter{}) + `</div>`
+}
+
+// PreviewHTML renders MarkdownV2 as an html message bubble styled by PreviewCSS
+// approximating how telegram shows the message, a *MarkdownError is returned for invalid text.
+func PreviewHTML(s string) (string, error) {
+	text, entities, err := ParseMarkdown(s)
+	if err != nil {
+		return "", err
+	}
+	return PreviewEntitiesHTML(text, entities), nil
+}
+
+// PreviewPage renders MarkdownV2 messages as a complete html page.
+func PreviewPage(title string, messages ...string) (string, error) {
+	var chunks []TextChunk
+	for _, m := range messages {
+		text, entities, err := ParseMarkdown(m)
+		if err != nil {
+			return "", err
+		}
+		chunks = append(chunks, TextChunk{Text: text, Entities: entities})
+	}
+	return PreviewEntitiesPage(title, chunks...), nil
+}
+
+// PreviewEntitiesPage renders messages of text with entities as a complete html page.
+func PreviewEntitiesPage(title string, messages ...TextChunk) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>" + NL + `<html><head><meta charset="utf-8">` + NL)
+	b.WriteString("<title>" + HtmlEsc(title) + "</title>" + NL)
+	b.WriteString("<style>" + NL + PreviewCSS + "</style>" + NL + "</head><body>" + NL)
+	for _, m := range messages {
+		b.WriteString(PreviewEntitiesHTML(m.Text, m.Entities) + NL)
+	}
+	b.WriteString("</body></html>" + NL)
+	return b.String()
+}
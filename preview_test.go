@@ -0,0 +1,57 @@
+package tg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPreviewHTML(t *testing.T) {
+
+	for _, tc := range []struct {
+		markdown string
+		html     string
+	}{
+		{
+			"*bold* _it_ ||secret|| [link](https://example.org/?a=1&b=2) `x<y`",
+			`<b>bold</b> <i>it</i> <span class="tg-spoiler" onclick="this.classList.add('tg-revealed')">secret</span> ` +
+				`<a href="https://example.org/?a=1&amp;b=2">link</a> <code>x&lt;y</code>`,
+		},
+		{
+			"run:\n```sh\nls -l\n```\ndone",
+			`run:<pre class="tg-pre"><span class="tg-pre-lang">sh</span><code>ls -l</code></pre>done`,
+		},
+		{
+			">quote\n>lines\nafter\n**>hidden\n>more||",
+			`<blockquote class="tg-quote">quote` + NL + `lines</blockquote>after` +
+				`<blockquote class="tg-quote tg-expandable" onclick="this.classList.toggle('tg-expanded')">hidden` + NL + `more</blockquote>`,
+		},
+		{
+			"![👍](tg://emoji?id=5368324170671202286) [Ann](tg://user?id=42)",
+			`<span class="tg-emoji" title="custom emoji 5368324170671202286">👍</span> <a href="tg://user?id=42">Ann</a>`,
+		},
+	} {
+		html, err := PreviewHTML(tc.markdown)
+		if err != nil {
+			t.Errorf("%q: %v", tc.markdown, err)
+			continue
+		}
+		if expected := `<div class="tg-message">` + tc.html + `</div>`; html != expected {
+			t.Errorf("PreviewHTML %q\n%s\nexpected\n%s", tc.markdown, html, expected)
+		}
+	}
+
+	var mderr *MarkdownError
+	if _, err := PreviewHTML("1.5"); !errors.As(err, &mderr) {
+		t.Errorf("PreviewHTML invalid markdown error %v", err)
+	}
+
+	page, err := PreviewPage("a<b", Bold("one"), Italic("two"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page, "<title>a&lt;b</title>") || strings.Count(page, `<div class="tg-message">`) != 2 {
+		t.Errorf("PreviewPage %s", page)
+	}
+
+}
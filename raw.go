@@ -0,0 +1,50 @@
+package tg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// UnmarshalJSON decodes the message keeping the json in Raw.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+	type message Message
+	if err := json.Unmarshal(data, (*message)(m)); err != nil {
+		return err
+	}
+	m.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// UnmarshalJSON decodes the update keeping the json in Raw.
+func (u *Update) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+	type update Update
+	if err := json.Unmarshal(data, (*update)(u)); err != nil {
+		return err
+	}
+	u.Raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// RawField decodes the field name of the raw json object into v.
+// It reports false if there is no such field.
+func RawField(raw json.RawMessage, name string, v interface{}) (ok bool, err error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return false, fmt.Errorf("json.Unmarshal %w", err)
+	}
+	field, ok := fields[name]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(field, v); err != nil {
+		return true, fmt.Errorf("json.Unmarshal %s %w", name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,72 @@
+package tg
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestRaw(t *testing.T) {
+
+	updatejson := `{"update_id":7,"message":{"message_id":3,"chat":{"id":5,"type":"private"},"date":1,"text":"hi","new_field":{"a":[1,2]},"reply_to_message":{"message_id":2,"chat":{"id":5,"type":"private"},"date":1,"new_reply_field":1}},"new_update":true}`
+
+	var u Update
+	if err := json.Unmarshal([]byte(updatejson), &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.UpdateId != 7 || u.Message.MessageId != 3 || u.Message.Text != "hi" {
+		t.Fatalf("update %#v", u)
+	}
+	if string(u.Raw) != updatejson {
+		t.Errorf("Raw %s", u.Raw)
+	}
+
+	var newfield struct{ A []int }
+	if ok, err := RawField(u.Message.Raw, "new_field", &newfield); !ok || err != nil || len(newfield.A) != 2 {
+		t.Errorf("RawField new_field ok %v err %v value %#v", ok, err, newfield)
+	}
+	if u.Message.ReplyToMessage == nil {
+		t.Fatalf("reply_to_message not decoded")
+	}
+	if ok, err := RawField(u.Message.ReplyToMessage.Raw, "new_reply_field", new(int)); !ok || err != nil {
+		t.Errorf("RawField new_reply_field ok %v err %v", ok, err)
+	}
+	if ok, err := RawField(u.Raw, "missing", &newfield); ok || err != nil {
+		t.Errorf("RawField missing ok %v err %v", ok, err)
+	}
+
+	// Raw is the archival form, json.Marshal serializes the decoded fields as edited
+	u.Message.Text = "edited"
+	data, err := json.Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u2 Update
+	if err := json.Unmarshal(data, &u2); err != nil || u2.Message.Text != "edited" {
+		t.Errorf("json.Unmarshal %s err %v", data, err)
+	}
+	var u3 Update
+	if err := json.Unmarshal(u.Raw, &u3); err != nil || u3.Message.Text != "hi" {
+		t.Errorf("json.Unmarshal Raw err %v", err)
+	}
+	if ok, err := RawField(u3.Raw, "new_update", new(bool)); !ok || err != nil {
+		t.Errorf("RawField new_update ok %v err %v", ok, err)
+	}
+
+}
+
+func TestSendMessageRaw(t *testing.T) {
+
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":{"message_id":4,"chat":{"id":5,"type":"private"},"date":1,"text":"hi","new_field":true}}`))
+	})
+
+	msg, err := SendMessage(SendMessageRequest{ChatId: "5", Text: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := RawField(msg.Raw, "new_field", new(bool)); !ok || err != nil {
+		t.Errorf("RawField new_field ok %v err %v", ok, err)
+	}
+
+}
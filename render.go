@@ -0,0 +1,362 @@
+package tg
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// https://core.telegram.org/bots/api#formatting-options
+
+// entityWriter writes text and entity boundaries in a particular markup.
+type entityWriter interface {
+	open(e MessageEntity)
+	close(e MessageEntity)
+	text(s string, code bool)
+	String() string
+}
+
+// entityNesting orders entities covering the same range from outer to inner ones.
+// Entities that do not affect formatting, like mentions and urls, are not rendered.
+func entityNesting(typ string) int {
+	switch typ {
+	case EntityTypeBlockquote, EntityTypeExpandableBlockquote:
+		return 0
+	case EntityTypeTextLink, EntityTypeTextMention:
+		return 1
+	case EntityTypeBold:
+		return 2
+	case EntityTypeItalic:
+		return 3
+	case EntityTypeUnderline:
+		return 4
+	case EntityTypeStrikethrough:
+		return 5
+	case EntityTypeSpoiler:
+		return 6
+	case EntityTypeCustomEmoji:
+		return 7
+	case EntityTypeCode, EntityTypePre:
+		return 8
+	}
+	return -1
+}
+
+func isCodeEntity(typ string) bool {
+	return typ == EntityTypeCode || typ == EntityTypePre
+}
+
+// renderEntities walks the text boundaries of entities and closes and reopens entities as needed
+// so that overlapping entities come out properly nested.
+// Nothing is nested inside code and pre entities.
+func renderEntities(text string, entities []MessageEntity, w entityWriter) string {
+	// byte offsets of utf16 offsets
+	bytepos := make([]int, 0, len(text)+1)
+	for i, r := range text {
+		bytepos = append(bytepos, i)
+		if utf16len(r) == 2 {
+			bytepos = append(bytepos, i)
+		}
+	}
+	bytepos = append(bytepos, len(text))
+	n := len(bytepos) - 1
+
+	var ee []MessageEntity
+	points := []int{0, n}
+	for _, e := range entities {
+		if entityNesting(e.Type) < 0 {
+			continue
+		}
+		start, end := e.Offset, e.Offset+e.Length
+		if start < 0 {
+			start = 0
+		}
+		if end > n {
+			end = n
+		}
+		if end <= start {
+			continue
+		}
+		e.Offset, e.Length = start, end-start
+		ee = append(ee, e)
+		points = append(points, start, end)
+	}
+	sort.SliceStable(ee, func(i, j int) bool {
+		if ee[i].Offset != ee[j].Offset {
+			return ee[i].Offset < ee[j].Offset
+		}
+		if ee[i].Length != ee[j].Length {
+			return ee[i].Length > ee[j].Length
+		}
+		return entityNesting(ee[i].Type) < entityNesting(ee[j].Type)
+	})
+	sort.Ints(points)
+	uniq := points[:1]
+	for _, p := range points[1:] {
+		if p != uniq[len(uniq)-1] {
+			uniq = append(uniq, p)
+		}
+	}
+	points = uniq
+
+	var stack []int
+	for i, p := range points {
+
+		var want []int
+		for j, e := range ee {
+			if e.Offset <= p && p < e.Offset+e.Length {
+				want = append(want, j)
+				if isCodeEntity(e.Type) {
+					break
+				}
+			}
+		}
+
+		common := 0
+		for common < len(stack) && common < len(want) && stack[common] == want[common] {
+			common++
+		}
+		for k := len(stack) - 1; k >= common; k-- {
+			w.close(ee[stack[k]])
+		}
+		stack = stack[:common]
+		for _, j := range want[common:] {
+			w.open(ee[j])
+			stack = append(stack, j)
+		}
+
+		if i+1 < len(points) {
+			code := len(stack) > 0 && isCodeEntity(ee[stack[len(stack)-1]].Type)
+			w.text(text[bytepos[p]:bytepos[points[i+1]]], code)
+		}
+	}
+
+	return w.String()
+}
+
+// EntitiesMarkdown renders text with entities as MarkdownV2.
+func EntitiesMarkdown(text string, entities []MessageEntity) string {
+	return renderEntities(text, entities, &markdownWriter{})
+}
+
+// EntitiesHTML renders text with entities as telegram HTML.
+func EntitiesHTML(text string, entities []MessageEntity) string {
+	return renderEntities(text, entities, &htmlWriter{})
+}
+
+func (m *Message) TextMarkdown() string {
+	return EntitiesMarkdown(m.Text, m.Entities)
+}
+
+func (m *Message) CaptionMarkdown() string {
+	return EntitiesMarkdown(m.Caption, m.CaptionEntities)
+}
+
+func (m *Message) TextHTML() string {
+	return EntitiesHTML(m.Text, m.Entities)
+}
+
+func (m *Message) CaptionHTML() string {
+	return EntitiesHTML(m.Caption, m.CaptionEntities)
+}
+
+type markdownWriter struct {
+	b []byte
+	// inside a block quote, and a new line has started that still needs the > prefix
+	quote, quotenl bool
+	// where the last block quote ended
+	quoteend int
+}
+
+func (w *markdownWriter) put(s string) {
+	for i := 0; i < len(s); i++ {
+		if w.quote && w.quotenl {
+			w.b = append(w.b, '>')
+			w.quotenl = false
+		}
+		w.b = append(w.b, s[i])
+		if s[i] == '\n' && w.quote {
+			w.quotenl = true
+		}
+	}
+}
+
+func (w *markdownWriter) marker(s string) {
+	// ___ is ambiguous between italic and underline so adjacent markers are separated with \r which telegram ignores
+	if strings.HasPrefix(s, "_") && bytes.HasSuffix(w.b, []byte("_")) {
+		w.put("\r")
+	}
+	w.put(s)
+}
+
+func (w *markdownWriter) open(e MessageEntity) {
+	switch e.Type {
+	case EntityTypeBold:
+		w.marker("*")
+	case EntityTypeItalic:
+		w.marker("_")
+	case EntityTypeUnderline:
+		w.marker("__")
+	case EntityTypeStrikethrough:
+		w.marker("~")
+	case EntityTypeSpoiler:
+		w.marker("||")
+	case EntityTypeCode:
+		w.marker("`")
+	case EntityTypePre:
+		w.marker("```" + e.Language + NL)
+	case EntityTypeTextLink, EntityTypeTextMention:
+		w.marker("[")
+	case EntityTypeCustomEmoji:
+		w.marker("![")
+	case EntityTypeBlockquote, EntityTypeExpandableBlockquote:
+		// an empty bold entity separates the quote from a quote on the previous line
+		if w.quoteend > 0 && string(w.b[w.quoteend:]) == NL {
+			w.put("**")
+		}
+		w.put(">")
+		w.quote, w.quotenl = true, false
+	}
+}
+
+func (w *markdownWriter) close(e MessageEntity) {
+	switch e.Type {
+	case EntityTypeBold:
+		w.marker("*")
+	case EntityTypeItalic:
+		w.marker("_")
+	case EntityTypeUnderline:
+		w.marker("__")
+	case EntityTypeStrikethrough:
+		w.marker("~")
+	case EntityTypeSpoiler:
+		w.marker("||")
+	case EntityTypeCode:
+		w.marker("`")
+	case EntityTypePre:
+		w.marker(NL + "```")
+	case EntityTypeTextLink:
+		w.put("](" + escMarkdownUrl(e.Url) + ")")
+	case EntityTypeTextMention:
+		var userid int64
+		if e.User != nil {
+			userid = e.User.Id
+		}
+		w.put(F("](tg://user?id=%d)", userid))
+	case EntityTypeCustomEmoji:
+		w.put("](tg://emoji?id=" + escMarkdownUrl(e.CustomEmojiId) + ")")
+	case EntityTypeBlockquote:
+		w.quote, w.quotenl = false, false
+		w.quoteend = len(w.b)
+	case EntityTypeExpandableBlockquote:
+		// the expandability mark goes at the end of the last quote line
+		if w.quotenl {
+			w.b = append(w.b[:len(w.b)-1], "||\n"...)
+			w.quoteend = len(w.b) - 1
+		} else {
+			w.put("||")
+			w.quoteend = len(w.b)
+		}
+		w.quote, w.quotenl = false, false
+	}
+}
+
+func (w *markdownWriter) text(s string, code bool) {
+	if code {
+		w.put(escMarkdownCode(s))
+	} else {
+		w.put(Esc(s))
+	}
+}
+
+func (w *markdownWriter) String() string {
+	return string(w.b)
+}
+
+func escMarkdownCode(s string) string {
+	// https://core.telegram.org/bots/api#markdownv2-style
+	return strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(s)
+}
+
+func escMarkdownUrl(s string) string {
+	// https://core.telegram.org/bots/api#markdownv2-style
+	return strings.NewReplacer("\\", "\\\\", ")", "\\)").Replace(s)
+}
+
+type htmlWriter struct {
+	b strings.Builder
+}
+
+func (w *htmlWriter) open(e MessageEntity) {
+	switch e.Type {
+	case EntityTypeBold:
+		w.b.WriteString("<b>")
+	case EntityTypeItalic:
+		w.b.WriteString("<i>")
+	case EntityTypeUnderline:
+		w.b.WriteString("<u>")
+	case EntityTypeStrikethrough:
+		w.b.WriteString("<s>")
+	case EntityTypeSpoiler:
+		w.b.WriteString("<tg-spoiler>")
+	case EntityTypeCode:
+		w.b.WriteString("<code>")
+	case EntityTypePre:
+		if e.Language != "" {
+			w.b.WriteString(`<pre><code class="language-` + HtmlEsc(e.Language) + `">`)
+		} else {
+			w.b.WriteString("<pre>")
+		}
+	case EntityTypeTextLink:
+		w.b.WriteString(`<a href="` + HtmlEsc(e.Url) + `">`)
+	case EntityTypeTextMention:
+		var userid int64
+		if e.User != nil {
+			userid = e.User.Id
+		}
+		w.b.WriteString(F(`<a href="tg://user?id=%d">`, userid))
+	case EntityTypeCustomEmoji:
+		w.b.WriteString(`<tg-emoji emoji-id="` + HtmlEsc(e.CustomEmojiId) + `">`)
+	case EntityTypeBlockquote:
+		w.b.WriteString("<blockquote>")
+	case EntityTypeExpandableBlockquote:
+		w.b.WriteString("<blockquote expandable>")
+	}
+}
+
+func (w *htmlWriter) close(e MessageEntity) {
+	switch e.Type {
+	case EntityTypeBold:
+		w.b.WriteString("</b>")
+	case EntityTypeItalic:
+		w.b.WriteString("</i>")
+	case EntityTypeUnderline:
+		w.b.WriteString("</u>")
+	case EntityTypeStrikethrough:
+		w.b.WriteString("</s>")
+	case EntityTypeSpoiler:
+		w.b.WriteString("</tg-spoiler>")
+	case EntityTypeCode:
+		w.b.WriteString("</code>")
+	case EntityTypePre:
+		if e.Language != "" {
+			w.b.WriteString("</code></pre>")
+		} else {
+			w.b.WriteString("</pre>")
+		}
+	case EntityTypeTextLink, EntityTypeTextMention:
+		w.b.WriteString("</a>")
+	case EntityTypeCustomEmoji:
+		w.b.WriteString("</tg-emoji>")
+	case EntityTypeBlockquote, EntityTypeExpandableBlockquote:
+		w.b.WriteString("</blockquote>")
+	}
+}
+
+func (w *htmlWriter) text(s string, code bool) {
+	w.b.WriteString(HtmlEsc(s))
+}
+
+func (w *htmlWriter) String() string {
+	return w.b.String()
+}
@@ -0,0 +1,52 @@
+package tg
+
+import (
+	"testing"
+)
+
+func TestEntitiesMarkdown(t *testing.T) {
+
+	for _, tc := range []struct {
+		text     string
+		entities []MessageEntity
+		expected string
+	}{
+		{"bold", []MessageEntity{{Type: EntityTypeBold, Offset: 0, Length: 4}}, Bold("bold")},
+		{"italic", []MessageEntity{{Type: EntityTypeItalic, Offset: 0, Length: 6}}, Italic("italic")},
+		{"spoiler", []MessageEntity{{Type: EntityTypeSpoiler, Offset: 0, Length: 7}}, Spoiler("spoiler")},
+		{"a`b", []MessageEntity{{Type: EntityTypeCode, Offset: 0, Length: 3}}, Code("a`b")},
+		{"bots api link", []MessageEntity{{Type: EntityTypeTextLink, Offset: 0, Length: 13, Url: "https://core.telegram.org/bots/api"}}, Link("bots api link", "https://core.telegram.org/bots/api")},
+		{"  pre * " + NL + "  * formatted", []MessageEntity{{Type: EntityTypePre, Offset: 0, Length: 22}}, Pre("  pre * " + NL + "  * formatted")},
+		{"quote" + NL + "lines" + NL, []MessageEntity{{Type: EntityTypeBlockquote, Offset: 0, Length: 11}}, Quote("quote" + NL + "lines")},
+		{"hidden" + NL + "lines" + NL, []MessageEntity{{Type: EntityTypeExpandableBlockquote, Offset: 0, Length: 12}}, ExpandQuote("hidden" + NL + "lines")},
+		{"1.5 😀 text", []MessageEntity{{Type: EntityTypeUrl, Offset: 0, Length: 3}, {Type: EntityTypeBold, Offset: 4, Length: 2}}, "1\\.5 *😀* text"},
+		// overlapping entities are split
+		{"abcdef", []MessageEntity{{Type: EntityTypeBold, Offset: 0, Length: 4}, {Type: EntityTypeItalic, Offset: 2, Length: 4}}, "*ab_cd_*_ef_"},
+		// italic and underline markers are separated
+		{"iu", []MessageEntity{{Type: EntityTypeItalic, Offset: 0, Length: 2}, {Type: EntityTypeUnderline, Offset: 0, Length: 2}}, "_\r__iu__\r_"},
+		{"go code", []MessageEntity{{Type: EntityTypePre, Offset: 0, Length: 7, Language: "go"}, {Type: EntityTypeBold, Offset: 0, Length: 2}}, "```go\ngo code\n```"},
+		{"q1\nq2", []MessageEntity{{Type: EntityTypeBlockquote, Offset: 0, Length: 2}, {Type: EntityTypeBlockquote, Offset: 3, Length: 2}}, ">q1\n**>q2"},
+		{"😀", []MessageEntity{{Type: EntityTypeCustomEmoji, Offset: 0, Length: 2, CustomEmojiId: "123"}}, "![😀](tg://emoji?id=123)"},
+		{"name", []MessageEntity{{Type: EntityTypeTextMention, Offset: 0, Length: 4, User: &User{Id: 42}}}, "[name](tg://user?id=42)"},
+	} {
+		if md := EntitiesMarkdown(tc.text, tc.entities); md != tc.expected {
+			t.Errorf("EntitiesMarkdown %q == %q expected %q", tc.text, md, tc.expected)
+		}
+	}
+
+}
+
+func TestEntitiesHTML(t *testing.T) {
+
+	text := "a<b> & c"
+	entities := []MessageEntity{
+		{Type: EntityTypeBold, Offset: 0, Length: 4},
+		{Type: EntityTypeTextLink, Offset: 2, Length: 6, Url: `https://example.org/?a="b"`},
+		{Type: EntityTypePre, Offset: 7, Length: 1, Language: "go"},
+	}
+	expected := `<b>a&lt;<a href="https://example.org/?a=&quot;b&quot;">b&gt;</a></b><a href="https://example.org/?a=&quot;b&quot;"> &amp; <pre><code class="language-go">c</code></pre></a>`
+	if h := EntitiesHTML(text, entities); h != expected {
+		t.Errorf("EntitiesHTML %q expected %q", h, expected)
+	}
+
+}
@@ -0,0 +1,201 @@
+package tg
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// https://core.telegram.org/bots/api#sendmessage
+	MessageTextMaxLen = 4096
+	// https://core.telegram.org/bots/api#sendphoto
+	CaptionMaxLen = 1024
+)
+
+// TextChunk is a part of a text with the entities clipped to it.
+type TextChunk struct {
+	Text     string
+	Entities []MessageEntity
+}
+
+// SplitText splits text into chunks of at most maxlen UTF-16 code units
+// preferring paragraph, then line, then word boundaries.
+// Entities crossing a boundary are closed in one chunk and reopened in the next.
+// Use MessageTextMaxLen for messages and CaptionMaxLen for captions.
+func SplitText(text string, entities []MessageEntity, maxlen int) []TextChunk {
+	if maxlen <= 0 {
+		maxlen = MessageTextMaxLen
+	}
+	return splitText(text, entities, func(int) int { return maxlen })
+}
+
+// SplitCaption splits text into a caption of at most CaptionMaxLen
+// followed by messages of at most MessageTextMaxLen UTF-16 code units
+// to send the overflow of a long caption as replies to the media message.
+func SplitCaption(text string, entities []MessageEntity) (caption TextChunk, messages []TextChunk) {
+	chunks := splitText(text, entities, func(n int) int {
+		if n == 0 {
+			return CaptionMaxLen
+		}
+		return MessageTextMaxLen
+	})
+	if len(chunks) == 0 {
+		return TextChunk{}, nil
+	}
+	return chunks[0], chunks[1:]
+}
+
+// splitText splits text into chunks, the chunk number n being of at most maxlen(n) UTF-16 code units.
+func splitText(text string, entities []MessageEntity, maxlen func(n int) int) []TextChunk {
+	rs := []rune(text)
+	pos := make([]int, len(rs)+1)
+	for i, r := range rs {
+		pos[i+1] = pos[i] + utf16len(r)
+	}
+
+	// cutting inside these would garble them
+	unsplittable := func(i int) bool {
+		for _, e := range entities {
+			if e.Type == EntityTypeCustomEmoji && e.Offset < pos[i] && pos[i] < e.Offset+e.Length {
+				return true
+			}
+		}
+		return false
+	}
+	// whitespace inside these is content
+	incode := func(i int) bool {
+		for _, e := range entities {
+			if (e.Type == EntityTypeCode || e.Type == EntityTypePre) && e.Offset <= pos[i] && pos[i] < e.Offset+e.Length {
+				return true
+			}
+		}
+		return false
+	}
+
+	var chunks []TextChunk
+	for start := 0; start < len(rs); {
+		limit := maxlen(len(chunks))
+		end := len(rs)
+		if pos[end]-pos[start] > limit {
+			end = start
+			for end < len(rs) && pos[end+1]-pos[start] <= limit {
+				end++
+			}
+			if end == start {
+				end++
+			}
+			end = splitPoint(rs, start, end, unsplittable)
+		}
+
+		// whitespace at the boundary is dropped unless it is code
+		next := end
+		for end > start && strings.ContainsRune(" \t\n", rs[end-1]) && !incode(end-1) && next < len(rs) {
+			end--
+		}
+		if end > start {
+			chunks = append(chunks, clipChunk(string(rs[start:end]), pos[start], pos[end], entities))
+		}
+		start = next
+		for start < len(rs) && rs[start] == '\n' && !incode(start) {
+			start++
+		}
+	}
+	return chunks
+}
+
+// splitPoint finds the position to end a chunk at between start and end.
+func splitPoint(rs []rune, start, end int, unsplittable func(int) bool) int {
+	for _, sep := range []func(i int) bool{
+		func(i int) bool { return rs[i-1] == '\n' && i > 1 && rs[i-2] == '\n' },
+		func(i int) bool { return rs[i-1] == '\n' },
+		func(i int) bool { return rs[i-1] == ' ' || rs[i-1] == '\t' },
+	} {
+		for i := end; i > start+1; i-- {
+			if sep(i) && !unsplittable(i) {
+				return i
+			}
+		}
+	}
+	for i := end; i > start+1; i-- {
+		if !unsplittable(i) {
+			return i
+		}
+	}
+	return end
+}
+
+func clipChunk(text string, start, end int, entities []MessageEntity) TextChunk {
+	chunk := TextChunk{Text: text}
+	for _, e := range entities {
+		offset, eend := max(e.Offset, start), min(e.Offset+e.Length, end)
+		if eend <= offset {
+			continue
+		}
+		e.Offset, e.Length = offset-start, eend-offset
+		chunk.Entities = append(chunk.Entities, e)
+	}
+	return chunk
+}
+
+// SplitMarkdown splits MarkdownV2 text into parts each parsing to at most maxlen UTF-16 code units.
+func SplitMarkdown(s string, maxlen int) ([]string, error) {
+	text, entities, err := ParseMarkdown(s)
+	if err != nil {
+		return nil, err
+	}
+	var parts []string
+	for _, chunk := range SplitText(text, entities, maxlen) {
+		parts = append(parts, EntitiesMarkdown(chunk.Text, chunk.Entities))
+	}
+	return parts, nil
+}
+
+// SendLongMessage sends text longer than MessageTextMaxLen as several messages
+// each replying to the previous one.
+// MarkdownV2 text is parsed locally and the parts are sent with entities.
+func SendLongMessage(req SendMessageRequest) (mm []*Message, err error) {
+	// https://core.telegram.org/bots/api#sendmessage
+
+	if req.Markdown.s != "" {
+		req.Text, req.ParseMode = req.Markdown.s, ParseModeMarkdownV2
+		req.Markdown = Markdown{}
+	}
+	if req.ParseMode == "" && req.Entities == nil {
+		req.ParseMode = ParseMode
+	}
+
+	text, entities := req.Text, req.Entities
+	switch req.ParseMode {
+	case "":
+	case ParseModeMarkdownV2:
+		if text, entities, err = ParseMarkdown(req.Text); err != nil {
+			return nil, fmt.Errorf("ParseMarkdown %w", err)
+		}
+	default:
+		if Utf16Len(req.Text) > MessageTextMaxLen {
+			return nil, fmt.Errorf("SendLongMessage ParseMode %s is not supported", req.ParseMode)
+		}
+		text, entities = "", nil
+	}
+
+	chunks := []TextChunk{{Text: req.Text, Entities: req.Entities}}
+	if text != "" {
+		chunks = SplitText(text, entities, MessageTextMaxLen)
+		req.ParseMode = ""
+	}
+
+	for _, chunk := range chunks {
+		req.Text, req.Entities = chunk.Text, chunk.Entities
+		if req.ParseMode == "" && req.Entities == nil {
+			req.Entities = []MessageEntity{}
+		}
+		msg, err := SendMessage(req)
+		if err != nil {
+			return mm, err
+		}
+		mm = append(mm, msg)
+		req.ReplyToMessageId = msg.MessageId
+	}
+
+	return mm, nil
+}
@@ -0,0 +1,150 @@
+package tg
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+
+	for _, tc := range []struct {
+		text     string
+		entities []MessageEntity
+		maxlen   int
+		chunks   []TextChunk
+	}{
+		{
+			text:   "short",
+			maxlen: 10,
+			chunks: []TextChunk{{Text: "short"}},
+		},
+		{
+			text:   "para one\nline\n\npara two",
+			maxlen: 20,
+			chunks: []TextChunk{{Text: "para one\nline"}, {Text: "para two"}},
+		},
+		{
+			text:   "line one\nline two",
+			maxlen: 12,
+			chunks: []TextChunk{{Text: "line one"}, {Text: "line two"}},
+		},
+		{
+			text:   "some words here",
+			maxlen: 11,
+			chunks: []TextChunk{{Text: "some words"}, {Text: "here"}},
+		},
+		{
+			text:   "abcdefgh",
+			maxlen: 3,
+			chunks: []TextChunk{{Text: "abc"}, {Text: "def"}, {Text: "gh"}},
+		},
+		{
+			text:   "👍👍👍",
+			maxlen: 3,
+			chunks: []TextChunk{{Text: "👍"}, {Text: "👍"}, {Text: "👍"}},
+		},
+		{
+			text:     "x\nfunc a() {\n}\nfunc b() {\n}",
+			entities: []MessageEntity{{Type: EntityTypePre, Offset: 2, Length: 25, Language: "go"}},
+			maxlen:   16,
+			chunks: []TextChunk{
+				// the newline inside pre is kept
+				{Text: "x\nfunc a() {\n}\n", Entities: []MessageEntity{{Type: EntityTypePre, Offset: 2, Length: 13, Language: "go"}}},
+				{Text: "func b() {\n}", Entities: []MessageEntity{{Type: EntityTypePre, Offset: 0, Length: 12, Language: "go"}}},
+			},
+		},
+		{
+			text: "ab 😀😀",
+			entities: []MessageEntity{
+				{Type: EntityTypeBold, Offset: 0, Length: 7},
+				{Type: EntityTypeCustomEmoji, Offset: 3, Length: 4, CustomEmojiId: "1"},
+			},
+			maxlen: 5,
+			chunks: []TextChunk{
+				{Text: "ab", Entities: []MessageEntity{{Type: EntityTypeBold, Offset: 0, Length: 2}}},
+				{Text: "😀😀", Entities: []MessageEntity{
+					{Type: EntityTypeBold, Offset: 0, Length: 4},
+					{Type: EntityTypeCustomEmoji, Offset: 0, Length: 4, CustomEmojiId: "1"},
+				}},
+			},
+		},
+	} {
+		if chunks := SplitText(tc.text, tc.entities, tc.maxlen); !reflect.DeepEqual(chunks, tc.chunks) {
+			t.Errorf("SplitText %q %d == %+v expected %+v", tc.text, tc.maxlen, chunks, tc.chunks)
+		}
+	}
+
+}
+
+func TestSplitMarkdown(t *testing.T) {
+
+	parts, err := SplitMarkdown("*bold text\\. more bold*\n```\ncode\ncode\n```", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"*bold text\\.*", "*more bold*", "```\ncode\ncode\n```"}
+	if !reflect.DeepEqual(parts, expected) {
+		t.Errorf("SplitMarkdown %q expected %q", parts, expected)
+	}
+	for _, p := range parts {
+		if err := ValidateMarkdown(p); err != nil {
+			t.Errorf("%q: %v", p, err)
+		}
+	}
+
+}
+
+func TestSplitCaption(t *testing.T) {
+
+	line := strings.Repeat("a", 99) + NL
+	text := strings.Repeat(line, 50)
+	caption, messages := SplitCaption(text, []MessageEntity{{Type: EntityTypeBold, Offset: 0, Length: Utf16Len(text)}})
+	if len(caption.Text) != 10*len(line)-1 || len(messages) != 1 || len(messages[0].Text) != 40*len(line) {
+		t.Fatalf("caption length %d messages %d", len(caption.Text), len(messages))
+	}
+	if len(caption.Entities) != 1 || caption.Entities[0].Length != len(caption.Text) || len(messages[0].Entities) != 1 || messages[0].Entities[0].Length != len(messages[0].Text) {
+		t.Errorf("caption entities %+v messages entities %+v", caption.Entities, messages[0].Entities)
+	}
+
+	if caption, messages := SplitCaption("short", nil); caption.Text != "short" || len(messages) != 0 {
+		t.Errorf("caption %+v messages %+v", caption, messages)
+	}
+
+}
+
+func TestSendLongMessage(t *testing.T) {
+
+	var reqs []SendMessageRequest
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		var req SendMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		reqs = append(reqs, req)
+		w.Write([]byte(F(`{"ok":true,"result":{"message_id":%d}}`, 100+len(reqs))))
+	})
+
+	line := strings.Repeat("a", 99) + NL
+	mm, err := SendLongMessage(SendMessageRequest{ChatId: "1", ReplyToMessageId: 7, Markdown: RawMarkdown(Bold(strings.Repeat(line, 50)))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mm) != 2 || len(reqs) != 2 {
+		t.Fatalf("sent %d messages", len(reqs))
+	}
+	if reqs[0].ReplyToMessageId != 7 || reqs[1].ReplyToMessageId != 101 {
+		t.Errorf("reply chain %d %d", reqs[0].ReplyToMessageId, reqs[1].ReplyToMessageId)
+	}
+	for _, req := range reqs {
+		if req.ParseMode != "" || len(req.Entities) != 1 || req.Entities[0].Type != EntityTypeBold {
+			t.Errorf("parse_mode %q entities %+v", req.ParseMode, req.Entities)
+		}
+	}
+	if len(reqs[0].Text) != 40*len(line)-1 || len(reqs[1].Text) != 10*len(line) {
+		t.Errorf("text lengths %d %d", len(reqs[0].Text), len(reqs[1].Text))
+	}
+
+}
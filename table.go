@@ -0,0 +1,236 @@
+package tg
+
+import (
+	"strings"
+	"unicode"
+)
+
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignRight
+	AlignCenter
+)
+
+type TableOptions struct {
+	// alignment of the columns, left by default
+	Align []Align
+	// truncate cells wider than this display width, 0 means no limit
+	MaxWidth int
+	// first row is the header and is separated by a line
+	Header bool
+	// draw borders with box drawing characters
+	Box bool
+}
+
+// wide and emoji presentation ranges of
+// https://www.unicode.org/reports/tr11/ and https://www.unicode.org/reports/tr51/
+var wideRanges = [][2]rune{
+	{0x1100, 0x115f}, {0x231a, 0x231b}, {0x2329, 0x232a}, {0x23e9, 0x23ec}, {0x23f0, 0x23f0}, {0x23f3, 0x23f3},
+	{0x25fd, 0x25fe}, {0x2614, 0x2615}, {0x2648, 0x2653}, {0x267f, 0x267f}, {0x2693, 0x2693}, {0x26a1, 0x26a1},
+	{0x26aa, 0x26ab}, {0x26bd, 0x26be}, {0x26c4, 0x26c5}, {0x26ce, 0x26ce}, {0x26d4, 0x26d4}, {0x26ea, 0x26ea},
+	{0x26f2, 0x26f3}, {0x26f5, 0x26f5}, {0x26fa, 0x26fa}, {0x26fd, 0x26fd}, {0x2705, 0x2705}, {0x270a, 0x270b},
+	{0x2728, 0x2728}, {0x274c, 0x274c}, {0x274e, 0x274e}, {0x2753, 0x2755}, {0x2757, 0x2757}, {0x2795, 0x2797},
+	{0x27b0, 0x27b0}, {0x27bf, 0x27bf}, {0x2b1b, 0x2b1c}, {0x2b50, 0x2b50}, {0x2b55, 0x2b55}, {0x2e80, 0x303e},
+	{0x3041, 0x33ff}, {0x3400, 0x4dbf}, {0x4e00, 0x9fff}, {0xa000, 0xa4cf}, {0xa960, 0xa97f}, {0xac00, 0xd7a3},
+	{0xf900, 0xfaff}, {0xfe10, 0xfe19}, {0xfe30, 0xfe6f}, {0xff00, 0xff60}, {0xffe0, 0xffe6}, {0x16fe0, 0x18cff},
+	{0x1b000, 0x1b2ff}, {0x1f004, 0x1f004}, {0x1f0cf, 0x1f0cf}, {0x1f18e, 0x1f18e}, {0x1f191, 0x1f19a},
+	{0x1f200, 0x1f251}, {0x1f300, 0x1f320}, {0x1f32d, 0x1f335}, {0x1f337, 0x1f37c}, {0x1f37e, 0x1f393},
+	{0x1f3a0, 0x1f3ca}, {0x1f3cf, 0x1f3d3}, {0x1f3e0, 0x1f3f0}, {0x1f3f4, 0x1f3f4}, {0x1f3f8, 0x1f43e},
+	{0x1f440, 0x1f440}, {0x1f442, 0x1f4fc}, {0x1f4ff, 0x1f53d}, {0x1f54b, 0x1f54e}, {0x1f550, 0x1f567},
+	{0x1f57a, 0x1f57a}, {0x1f595, 0x1f596}, {0x1f5a4, 0x1f5a4}, {0x1f5fb, 0x1f64f}, {0x1f680, 0x1f6c5},
+	{0x1f6cc, 0x1f6cc}, {0x1f6d0, 0x1f6d2}, {0x1f6d5, 0x1f6d7}, {0x1f6dc, 0x1f6df}, {0x1f6eb, 0x1f6ec},
+	{0x1f6f4, 0x1f6fc}, {0x1f7e0, 0x1f7eb}, {0x1f7f0, 0x1f7f0}, {0x1f90c, 0x1f93a}, {0x1f93c, 0x1f945},
+	{0x1f947, 0x1f9ff}, {0x1fa70, 0x1faff}, {0x20000, 0x2fffd}, {0x30000, 0x3fffd},
+}
+
+// RuneWidth returns the number of monospace cells the rune takes.
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0 || r == '‍' || r >= 0x1f3fb && r <= 0x1f3ff:
+		// zero width joiner and emoji skin tone modifiers
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case r < 0x1100:
+		return 1
+	}
+	for _, wr := range wideRanges {
+		if r < wr[0] {
+			break
+		}
+		if r <= wr[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// displayClusters calls yield with each run of s shown as one character,
+// a rune with the zero width runes and the emoji joined to it, and its width.
+func displayClusters(s string, yield func(cluster string, width int) bool) {
+	start, width := 0, 0
+	var zwj bool
+	for i, r := range s {
+		rw := RuneWidth(r)
+		// the emoji after a zero width joiner combines with the previous one
+		if i > 0 && !zwj && rw > 0 {
+			if !yield(s[start:i], width) {
+				return
+			}
+			start, width = i, 0
+		}
+		if !zwj {
+			width += rw
+		}
+		zwj = r == '‍'
+	}
+	if start < len(s) {
+		yield(s[start:], width)
+	}
+}
+
+// DisplayWidth returns the number of monospace cells the text takes,
+// counting east asian wide characters and emoji as two.
+func DisplayWidth(s string) (w int) {
+	displayClusters(s, func(_ string, cw int) bool {
+		w += cw
+		return true
+	})
+	return w
+}
+
+// TruncateWidth shortens s to the display width ending it with an ellipsis,
+// never cutting inside an emoji sequence or before a combining mark.
+func TruncateWidth(s string, width int) string {
+	if DisplayWidth(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	var w int
+	displayClusters(s, func(cluster string, cw int) bool {
+		if w+cw > width-1 {
+			return false
+		}
+		b.WriteString(cluster)
+		w += cw
+		return true
+	})
+	return b.String() + "…"
+}
+
+func padWidth(s string, width int, align Align) string {
+	pad := width - DisplayWidth(s)
+	if pad <= 0 {
+		return s
+	}
+	switch align {
+	case AlignRight:
+		return strings.Repeat(SP, pad) + s
+	case AlignCenter:
+		return strings.Repeat(SP, pad/2) + s + strings.Repeat(SP, pad-pad/2)
+	}
+	return s + strings.Repeat(SP, pad)
+}
+
+// TableText renders rows as monospaced text with aligned columns.
+func TableText(rows [][]string, opts TableOptions) string {
+	var ncols int
+	for _, row := range rows {
+		ncols = max(ncols, len(row))
+	}
+	widths := make([]int, ncols)
+	cells := make([][]string, len(rows))
+	for r, row := range rows {
+		cells[r] = make([]string, ncols)
+		for c, cell := range row {
+			// one line per cell
+			cell = strings.Join(strings.Fields(cell), SP)
+			if opts.MaxWidth > 0 {
+				cell = TruncateWidth(cell, opts.MaxWidth)
+			}
+			cells[r][c] = cell
+			widths[c] = max(widths[c], DisplayWidth(cell))
+		}
+	}
+	align := func(c int) Align {
+		if c < len(opts.Align) {
+			return opts.Align[c]
+		}
+		return AlignLeft
+	}
+
+	var lines []string
+	border := func(left, fill, cross, right string) {
+		var parts []string
+		for _, w := range widths {
+			parts = append(parts, strings.Repeat(fill, w+2))
+		}
+		lines = append(lines, left+strings.Join(parts, cross)+right)
+	}
+
+	if opts.Box {
+		border("┌", "─", "┬", "┐")
+	}
+	for r, row := range cells {
+		var parts []string
+		for c, cell := range row {
+			parts = append(parts, padWidth(cell, widths[c], align(c)))
+		}
+		if opts.Box {
+			lines = append(lines, "│ "+strings.Join(parts, " │ ")+" │")
+		} else {
+			lines = append(lines, strings.TrimRight(strings.Join(parts, "  "), SP))
+		}
+		if r == 0 && opts.Header && len(cells) > 1 {
+			if opts.Box {
+				border("├", "─", "┼", "┤")
+			} else {
+				var seps []string
+				for _, w := range widths {
+					seps = append(seps, strings.Repeat("-", w))
+				}
+				lines = append(lines, strings.Join(seps, "  "))
+			}
+		}
+	}
+	if opts.Box {
+		border("└", "─", "┴", "┘")
+	}
+
+	return strings.Join(lines, NL)
+}
+
+// Table renders rows as a MarkdownV2 pre block with aligned columns.
+func Table(rows [][]string, opts TableOptions) string {
+	return Pre(TableText(rows, opts))
+}
+
+// BulletList renders the items escaped one per line with bullets.
+func BulletList(items ...string) string {
+	var lines []string
+	for _, item := range items {
+		lines = append(lines, listItem("• ", item))
+	}
+	return strings.Join(lines, NL)
+}
+
+// NumberedList renders the items escaped one per line with aligned numbers starting from 1.
+func NumberedList(items ...string) string {
+	width := len(F("%d", len(items)))
+	var lines []string
+	for i, item := range items {
+		lines = append(lines, listItem(F("%*d. ", width, i+1), item))
+	}
+	return strings.Join(lines, NL)
+}
+
+// listItem escapes the item indenting its continuation lines under the first one.
+func listItem(marker, item string) string {
+	indent := strings.Repeat(SP, DisplayWidth(marker))
+	return Esc(marker) + Esc(strings.ReplaceAll(item, NL, NL+indent))
+}
@@ -0,0 +1,110 @@
+package tg
+
+import (
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+
+	for _, tc := range []struct {
+		s string
+		w int
+	}{
+		{"api", 3},
+		{"日本語", 6},
+		{"한국", 4},
+		{"✅ ok", 5},
+		{"👍🏽", 2},
+		{"👨‍👩‍👧", 2},
+		{"🇩🇪", 2},
+		{"é", 1},
+		{"❤️", 1},
+	} {
+		if w := DisplayWidth(tc.s); w != tc.w {
+			t.Errorf("DisplayWidth %q == %d expected %d", tc.s, w, tc.w)
+		}
+	}
+
+	if s := TruncateWidth("日本語テキスト", 7); s != "日本語…" {
+		t.Errorf("TruncateWidth %q", s)
+	}
+	if s := TruncateWidth("short", 5); s != "short" {
+		t.Errorf("TruncateWidth %q", s)
+	}
+
+	for _, tc := range []struct {
+		s     string
+		width int
+		out   string
+	}{
+		{"👨‍👩‍👧 family", 3, "👨‍👩‍👧…"},
+		{"a👨‍👩‍👧 family", 3, "a…"},
+		{"étude", 2, "é…"},
+	} {
+		out := TruncateWidth(tc.s, tc.width)
+		if out != tc.out {
+			t.Errorf("TruncateWidth %q == %q expected %q", tc.s, out, tc.out)
+		}
+		if w := DisplayWidth(out); w > tc.width {
+			t.Errorf("DisplayWidth %q == %d expected at most %d", out, w, tc.width)
+		}
+	}
+
+}
+
+func TestTable(t *testing.T) {
+
+	rows := [][]string{
+		{"service", "status", "latency"},
+		{"api", "✅", "12ms"},
+		{"データベース", "❌ down", "1.5s"},
+	}
+
+	text := TableText(rows, TableOptions{Align: []Align{AlignLeft, AlignCenter, AlignRight}, Header: true})
+	expected := "" +
+		"service       status   latency\n" +
+		"------------  -------  -------\n" +
+		"api             ✅        12ms\n" +
+		"データベース  ❌ down     1.5s"
+	if text != expected {
+		t.Errorf("TableText\n%s\nexpected\n%s", text, expected)
+	}
+
+	text = TableText(rows, TableOptions{MaxWidth: 6, Header: true, Box: true})
+	expected = "" +
+		"┌────────┬────────┬────────┐\n" +
+		"│ servi… │ status │ laten… │\n" +
+		"├────────┼────────┼────────┤\n" +
+		"│ api    │ ✅     │ 12ms   │\n" +
+		"│ デー…  │ ❌ do… │ 1.5s   │\n" +
+		"└────────┴────────┴────────┘"
+	if text != expected {
+		t.Errorf("TableText\n%s\nexpected\n%s", text, expected)
+	}
+
+	if m := Table([][]string{{"a`b", "c\\d"}}, TableOptions{}); m != "```\na\\`b  c\\\\d\n```" {
+		t.Errorf("Table %q", m)
+	}
+
+}
+
+func TestLists(t *testing.T) {
+
+	if m := BulletList("one.", "two\nlines", "3-4"); m != "• one\\.\n• two\n  lines\n• 3\\-4" {
+		t.Errorf("BulletList %q", m)
+	}
+
+	var items []string
+	for i := 0; i < 10; i++ {
+		items = append(items, "x")
+	}
+	m := NumberedList(items...)
+	if err := ValidateMarkdown(m); err != nil {
+		t.Fatal(err)
+	}
+	text, _, _ := ParseMarkdown(m)
+	if text[:7] != " 1. x\n " || text[len(text)-5:] != "10. x" {
+		t.Errorf("NumberedList %q", text)
+	}
+
+}
@@ -0,0 +1,145 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+	"text/template/parse"
+
+	"github.com/shoce/tg"
+)
+
+// context is the MarkdownV2 position of the template output.
+type context int
+
+const (
+	ctxPlain context = iota
+	ctxCode
+	ctxPre
+	ctxUrl
+)
+
+func (c context) String() string {
+	switch c {
+	case ctxCode:
+		return "code"
+	case ctxPre:
+		return "pre"
+	case ctxUrl:
+		return "url"
+	}
+	return "plain"
+}
+
+// next returns the context after the literal template text.
+func (c context) next(text []byte) context {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+			continue
+		}
+		switch c {
+		case ctxPlain:
+			switch {
+			case strings.HasPrefix(string(text[i:]), "```"):
+				c = ctxPre
+				i += 2
+			case text[i] == '`':
+				c = ctxCode
+			case text[i] == ']' && i+1 < len(text) && text[i+1] == '(':
+				c = ctxUrl
+				i++
+			}
+		case ctxCode:
+			if text[i] == '`' {
+				c = ctxPlain
+			}
+		case ctxPre:
+			if strings.HasPrefix(string(text[i:]), "```") {
+				c = ctxPlain
+				i += 2
+			}
+		case ctxUrl:
+			if text[i] == ')' {
+				c = ctxPlain
+			}
+		}
+	}
+	return c
+}
+
+func (c context) escaper() string {
+	switch c {
+	case ctxCode, ctxPre:
+		return "_tg_esc_code"
+	case ctxUrl:
+		return "_tg_esc_url"
+	}
+	return "_tg_esc_plain"
+}
+
+var escapers = FuncMap{
+	"_tg_esc_plain": func(v interface{}) string {
+		if m, ok := v.(tg.Markdown); ok {
+			return m.String()
+		}
+		return tg.Esc(fmt.Sprint(v))
+	},
+	"_tg_esc_code": func(v interface{}) string {
+		return strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(fmt.Sprint(v))
+	},
+	"_tg_esc_url": func(v interface{}) string {
+		return strings.NewReplacer("\\", "\\\\", ")", "\\)").Replace(fmt.Sprint(v))
+	},
+}
+
+// escapeList adds escapers to the actions of the list starting in context c
+// and returns the context at its end.
+func escapeList(name string, list *parse.ListNode, c context) (context, error) {
+	if list == nil {
+		return c, nil
+	}
+	var err error
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.TextNode:
+			c = c.next(n.Text)
+		case *parse.ActionNode:
+			if len(n.Pipe.Decl) == 0 {
+				n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+					NodeType: parse.NodeCommand,
+					Pos:      n.Pos,
+					Args:     []parse.Node{parse.NewIdentifier(c.escaper()).SetPos(n.Pos)},
+				})
+			}
+		case *parse.IfNode:
+			c, err = escapeBranch(name, &n.BranchNode, c)
+		case *parse.RangeNode:
+			c, err = escapeBranch(name, &n.BranchNode, c)
+		case *parse.WithNode:
+			c, err = escapeBranch(name, &n.BranchNode, c)
+		case *parse.TemplateNode:
+			if c != ctxPlain {
+				err = fmt.Errorf("template %s: template %q called in %s context", name, n.Name, c)
+			}
+		}
+		if err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func escapeBranch(name string, n *parse.BranchNode, c context) (context, error) {
+	c1, err := escapeList(name, n.List, c)
+	if err != nil {
+		return c, err
+	}
+	c2, err := escapeList(name, n.ElseList, c)
+	if err != nil {
+		return c, err
+	}
+	if c1 != c2 || n.NodeType == parse.NodeRange && c1 != c {
+		return c, fmt.Errorf("template %s: branches end in different contexts %s and %s", name, c1, c2)
+	}
+	return c1, nil
+}
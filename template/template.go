@@ -0,0 +1,146 @@
+// Package template renders telegram MarkdownV2 messages with text/template
+// escaping the interpolated values by their position in the message
+// the way html/template does for html.
+//
+// Values in plain text are escaped with tg.Esc, values inside `code` and ```pre```
+// blocks and inside link urls get the escaping of those contexts.
+// Values of type tg.Markdown are trusted and inserted as is in plain text.
+package template
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/shoce/tg"
+)
+
+// https://core.telegram.org/bots/api#markdownv2-style
+// https://pkg.go.dev/html/template
+
+type FuncMap = template.FuncMap
+
+// Template is a text/template producing MarkdownV2.
+type Template struct {
+	text *template.Template
+	ns   *namespace
+}
+
+// namespace is shared by the associated templates.
+type namespace struct {
+	mu      sync.Mutex
+	escaped map[*parse.Tree]bool
+}
+
+func New(name string) *Template {
+	t := &Template{
+		text: template.New(name),
+		ns:   &namespace{escaped: make(map[*parse.Tree]bool)},
+	}
+	t.text.Funcs(escapers).Funcs(Funcs)
+	return t
+}
+
+func Must(t *Template, err error) *Template {
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func (t *Template) Name() string {
+	return t.text.Name()
+}
+
+func (t *Template) Funcs(funcs FuncMap) *Template {
+	t.text.Funcs(funcs)
+	return t
+}
+
+func (t *Template) Option(opt ...string) *Template {
+	t.text.Option(opt...)
+	return t
+}
+
+func (t *Template) Delims(left, right string) *Template {
+	t.text.Delims(left, right)
+	return t
+}
+
+// New allocates a template associated with t.
+func (t *Template) New(name string) *Template {
+	return &Template{text: t.text.New(name), ns: t.ns}
+}
+
+// Lookup returns the associated template with the name or nil.
+func (t *Template) Lookup(name string) *Template {
+	text := t.text.Lookup(name)
+	if text == nil {
+		return nil
+	}
+	return &Template{text: text, ns: t.ns}
+}
+
+// Parse parses text as the template body and escapes all associated templates.
+func (t *Template) Parse(text string) (*Template, error) {
+	if _, err := t.text.Parse(text); err != nil {
+		return nil, err
+	}
+	t.ns.mu.Lock()
+	defer t.ns.mu.Unlock()
+	for _, tt := range t.text.Templates() {
+		if tt.Tree == nil || t.ns.escaped[tt.Tree] {
+			continue
+		}
+		if _, err := escapeList(tt.Name(), tt.Tree.Root, ctxPlain); err != nil {
+			return nil, err
+		}
+		t.ns.escaped[tt.Tree] = true
+	}
+	return t, nil
+}
+
+func (t *Template) Execute(w io.Writer, data interface{}) error {
+	return t.text.Execute(w, data)
+}
+
+func (t *Template) ExecuteTemplate(w io.Writer, name string, data interface{}) error {
+	return t.text.ExecuteTemplate(w, name, data)
+}
+
+// ExecuteMarkdown executes the template into a tg.Markdown value.
+func (t *Template) ExecuteMarkdown(data interface{}) (tg.Markdown, error) {
+	var b strings.Builder
+	if err := t.text.Execute(&b, data); err != nil {
+		return tg.Markdown{}, err
+	}
+	return tg.RawMarkdown(b.String()), nil
+}
+
+// markdown converts v to tg.Markdown escaping it unless it already is.
+func markdown(v interface{}) tg.Markdown {
+	if m, ok := v.(tg.Markdown); ok {
+		return m
+	}
+	return tg.MarkdownText(fmt.Sprint(v))
+}
+
+// Funcs are the formatting functions available in templates.
+var Funcs = FuncMap{
+	"esc":           func(v interface{}) tg.Markdown { return markdown(v) },
+	"markdown":      func(s string) tg.Markdown { return tg.RawMarkdown(s) },
+	"bold":          func(v interface{}) tg.Markdown { return markdown(v).Bold() },
+	"italic":        func(v interface{}) tg.Markdown { return markdown(v).Italic() },
+	"underline":     func(v interface{}) tg.Markdown { return markdown(v).Underline() },
+	"strikethrough": func(v interface{}) tg.Markdown { return markdown(v).Strikethrough() },
+	"spoiler":       func(v interface{}) tg.Markdown { return markdown(v).Spoiler() },
+	"quote":         func(v interface{}) tg.Markdown { return markdown(v).Quote() },
+	"expandquote":   func(v interface{}) tg.Markdown { return markdown(v).ExpandQuote() },
+	"code":          func(v interface{}) tg.Markdown { return tg.MarkdownCode(fmt.Sprint(v)) },
+	"pre":           func(v interface{}) tg.Markdown { return tg.MarkdownPre(fmt.Sprint(v)) },
+	"prelang":       func(lang string, v interface{}) tg.Markdown { return tg.MarkdownPreLang(lang, fmt.Sprint(v)) },
+	"link":          func(text interface{}, url string) tg.Markdown { return markdown(text).Link(url) },
+}
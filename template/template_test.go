@@ -0,0 +1,79 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shoce/tg"
+)
+
+func TestTemplate(t *testing.T) {
+
+	type service struct {
+		Name    string
+		Status  string
+		Url     string
+		Latency float64
+	}
+	data := struct {
+		Host     string
+		Command  string
+		Output   string
+		Services []service
+		Note     tg.Markdown
+	}{
+		Host:    "db-1.example.org",
+		Command: "grep `x` a\\b",
+		Output:  "```\nok (1/2)",
+		Services: []service{
+			{Name: "api_v2", Status: "ok", Url: "https://example.org/(api)", Latency: 1.5},
+			{Name: "db*", Status: "down", Url: "https://example.org/db", Latency: 12},
+		},
+		Note: tg.MarkdownText("checked!").Italic(),
+	}
+
+	tmpl := Must(New("alert").Parse(
+		"*{{.Host}}* ran `{{.Command}}`:\n" +
+			"```\n{{.Output}}\n```\n" +
+			"{{range .Services}}{{if eq .Status \"ok\"}}✅{{else}}❌{{end}} [{{.Name}}]({{.Url}}) {{.Latency}}ms {{bold .Status}}\n{{end}}" +
+			"{{.Note}} {{link \"docs (v2)\" \"https://example.org/docs\"}} {{code .Command}}",
+	))
+	m, err := tmpl.ExecuteMarkdown(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "*db\\-1\\.example\\.org* ran `grep \\`x\\` a\\\\b`:\n" +
+		"```\n\\`\\`\\`\nok (1/2)\n```\n" +
+		"✅ [api\\_v2](https://example.org/(api\\)) 1\\.5ms *ok*\n" +
+		"❌ [db\\*](https://example.org/db) 12ms *down*\n" +
+		"_checked\\!_ [docs \\(v2\\)](https://example.org/docs) `grep \\`x\\` a\\\\b`"
+	if m.String() != expected {
+		t.Errorf("ExecuteMarkdown\n%s\nexpected\n%s", m, expected)
+	}
+
+	text, _, err := tg.ParseMarkdown(m.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"db-1.example.org", "grep `x` a\\b", "```\nok (1/2)", "api_v2", "db*", "checked!", "docs (v2)"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("text %q does not contain %q", text, s)
+		}
+	}
+
+}
+
+func TestTemplateErrors(t *testing.T) {
+
+	for _, text := range []string{
+		"{{if .}}`{{end}}",
+		"{{range .}}[x]({{end}}",
+		"{{define \"t\"}}{{.}}{{end}}`{{template \"t\" .}}`",
+	} {
+		if _, err := New("t").Parse(text); err == nil {
+			t.Errorf("%q: no error", text)
+		}
+	}
+
+}
@@ -0,0 +1,111 @@
+package tg
+
+import (
+	"sort"
+	"strings"
+)
+
+// TextBuilder accumulates plain text and its entities to send without a parse mode.
+// Entity offsets and lengths are counted in UTF-16 code units as Telegram expects.
+type TextBuilder struct {
+	b        strings.Builder
+	len      int
+	entities []MessageEntity
+}
+
+func (b *TextBuilder) Text(s string) *TextBuilder {
+	b.b.WriteString(s)
+	b.len += Utf16Len(s)
+	return b
+}
+
+func (b *TextBuilder) Textf(format string, a ...interface{}) *TextBuilder {
+	return b.Text(F(format, a...))
+}
+
+func (b *TextBuilder) NL() *TextBuilder {
+	return b.Text(NL)
+}
+
+// Nest adds entity e spanning everything appended by f.
+// Offset and Length of e are set by Nest.
+func (b *TextBuilder) Nest(e MessageEntity, f func(b *TextBuilder)) *TextBuilder {
+	offset := b.len
+	f(b)
+	if b.len > offset {
+		e.Offset, e.Length = offset, b.len-offset
+		b.entities = append(b.entities, e)
+	}
+	return b
+}
+
+// Entity appends s as an entity of the type.
+func (b *TextBuilder) Entity(typ, s string) *TextBuilder {
+	return b.Nest(MessageEntity{Type: typ}, func(b *TextBuilder) { b.Text(s) })
+}
+
+func (b *TextBuilder) Bold(s string) *TextBuilder {
+	return b.Entity(EntityTypeBold, s)
+}
+
+func (b *TextBuilder) Italic(s string) *TextBuilder {
+	return b.Entity(EntityTypeItalic, s)
+}
+
+func (b *TextBuilder) Underline(s string) *TextBuilder {
+	return b.Entity(EntityTypeUnderline, s)
+}
+
+func (b *TextBuilder) Strikethrough(s string) *TextBuilder {
+	return b.Entity(EntityTypeStrikethrough, s)
+}
+
+func (b *TextBuilder) Spoiler(s string) *TextBuilder {
+	return b.Entity(EntityTypeSpoiler, s)
+}
+
+func (b *TextBuilder) Code(s string) *TextBuilder {
+	return b.Entity(EntityTypeCode, s)
+}
+
+func (b *TextBuilder) Pre(s string) *TextBuilder {
+	return b.Entity(EntityTypePre, s)
+}
+
+func (b *TextBuilder) PreLang(lang, s string) *TextBuilder {
+	return b.Nest(MessageEntity{Type: EntityTypePre, Language: lang}, func(b *TextBuilder) { b.Text(s) })
+}
+
+func (b *TextBuilder) Link(s, url string) *TextBuilder {
+	return b.Nest(MessageEntity{Type: EntityTypeTextLink, Url: url}, func(b *TextBuilder) { b.Text(s) })
+}
+
+func (b *TextBuilder) Quote(s string) *TextBuilder {
+	return b.Entity(EntityTypeBlockquote, s)
+}
+
+func (b *TextBuilder) ExpandQuote(s string) *TextBuilder {
+	return b.Entity(EntityTypeExpandableBlockquote, s)
+}
+
+// Len returns the text length in UTF-16 code units.
+func (b *TextBuilder) Len() int {
+	return b.len
+}
+
+func (b *TextBuilder) String() string {
+	return b.b.String()
+}
+
+// Entities returns the entities ordered by offset, outer ones first.
+func (b *TextBuilder) Entities() []MessageEntity {
+	// not nil even without entities so that no ParseMode is applied
+	entities := append([]MessageEntity{}, b.entities...)
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].Offset != entities[j].Offset {
+			return entities[i].Offset < entities[j].Offset
+		}
+		return entities[i].Length > entities[j].Length
+	})
+	return entities
+}
@@ -0,0 +1,107 @@
+package tg
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTextBuilder(t *testing.T) {
+
+	var b TextBuilder
+	b.Text("hi 👋 ").Bold("Bob").NL()
+	b.Nest(MessageEntity{Type: EntityTypeBlockquote}, func(b *TextBuilder) {
+		b.Text("see ").Link("the *docs*", "https://example.org/").Text(" or ").Code("man 1 tg")
+	})
+	b.Italic("")
+
+	text := "hi 👋 Bob\nsee the *docs* or man 1 tg"
+	entities := []MessageEntity{
+		{Type: EntityTypeBold, Offset: 6, Length: 3},
+		{Type: EntityTypeBlockquote, Offset: 10, Length: 26},
+		{Type: EntityTypeTextLink, Offset: 14, Length: 10, Url: "https://example.org/"},
+		{Type: EntityTypeCode, Offset: 28, Length: 8},
+	}
+	if b.String() != text || b.Len() != Utf16Len(text) {
+		t.Errorf("text %q len %d", b.String(), b.Len())
+	}
+	if !reflect.DeepEqual(b.Entities(), entities) {
+		t.Errorf("entities %+v", b.Entities())
+	}
+
+	mdtext, mdentities, err := ParseMarkdown(EntitiesMarkdown(b.String(), b.Entities()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mdtext != text || !reflect.DeepEqual(mdentities, entities) {
+		t.Errorf("markdown round trip %q %+v", mdtext, mdentities)
+	}
+
+}
+
+func TestSendMessageEntities(t *testing.T) {
+
+	var req map[string]interface{}
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		req = nil
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`))
+	})
+
+	var b TextBuilder
+	b.Bold("1+1=2.")
+	if _, err := SendMessage(SendMessageRequest{ChatId: "1", Text: b.String(), Entities: b.Entities()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := req["parse_mode"]; ok {
+		t.Errorf("parse_mode sent with entities: %v", req)
+	}
+	if entities, ok := req["entities"].([]interface{}); !ok || len(entities) != 1 {
+		t.Errorf("entities %v", req["entities"])
+	}
+
+	// plain text without entities is not parsed either
+	var plain TextBuilder
+	plain.Text("1.5 *not bold*")
+	if _, err := SendMessage(SendMessageRequest{ChatId: "1", Text: plain.String(), Entities: plain.Entities()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := req["parse_mode"]; ok || req["text"] != "1.5 *not bold*" {
+		t.Errorf("plain TextBuilder text sent with parse_mode: %v", req)
+	}
+
+	if _, err := SendMessage(SendMessageRequest{ChatId: "1", Text: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if req["parse_mode"] != ParseMode {
+		t.Errorf("parse_mode %v", req["parse_mode"])
+	}
+
+}
+
+func TestSendVoiceFileCaptionEntities(t *testing.T) {
+
+	var captionentities string
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		captionentities = r.FormValue("caption_entities")
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"voice":{"file_id":"voice"}}}`))
+	})
+
+	var b TextBuilder
+	b.Text("note ").Spoiler("secret")
+	if _, err := SendVoiceFile(SendVoiceFileRequest{ChatId: "1", Caption: b.String(), CaptionEntities: b.Entities(), Voice: strings.NewReader("ogg")}); err != nil {
+		t.Fatal(err)
+	}
+	var entities []MessageEntity
+	if err := json.Unmarshal([]byte(captionentities), &entities); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entities, b.Entities()) {
+		t.Errorf("caption_entities %+v", entities)
+	}
+
+}
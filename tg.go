@@ -11,6 +11,7 @@ package tg
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -29,7 +30,14 @@ const (
 	ApiUrlDef = "https://api.telegram.org"
 
 	// https://core.telegram.org/bots/api#formatting-options
-	ParseMode = "MarkdownV2"
+	ParseModeMarkdownV2 = "MarkdownV2"
+	ParseModeHTML       = "HTML"
+
+	ParseModeDef = ParseModeMarkdownV2
+
+	// https://core.telegram.org/bots/api#sendmediagroup
+	MediaGroupMinLen = 2
+	MediaGroupMaxLen = 10
 )
 
 var (
@@ -42,6 +50,9 @@ var (
 	ApiUrl   = ApiUrlDef
 	ApiToken = ""
 
+	// parse mode used by requests with empty ParseMode
+	ParseMode = ParseModeDef
+
 	F = fmt.Sprintf
 )
 
@@ -120,6 +131,21 @@ func Pre(text string) string {
 	return "```" + NL + text + NL + "```"
 }
 
+func PreLang(lang, text string) string {
+	// https://core.telegram.org/bots/api#formatting-options
+	// the language runs up to the end of the opening line
+	lang = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '`' || r == '\\' {
+			return -1
+		}
+		return r
+	}, lang)
+	for _, c := range "\\`" {
+		text = strings.ReplaceAll(text, string(c), "\\"+string(c))
+	}
+	return "```" + lang + NL + text + NL + "```"
+}
+
 func Quote(text string) string {
 	// https://core.telegram.org/bots/api#formatting-options
 	text = ">" + text
@@ -137,42 +163,168 @@ func ExpandQuote(text string) string {
 
 type Message struct {
 	// https://core.telegram.org/bots/api#message
-	Id        string
-	MessageId int64 `json:"message_id"`
-
-	From       User   `json:"from,omitempty"`
-	SenderChat Chat   `json:"sender_chat"`
-	Date       uint64 `json:"date"`
-	Chat       Chat   `json:"chat"`
-
-	Text string `json:"text,omitempty"`
-
-	ReplyToMessage *Message `json:"reply_to_message"`
-
-	Audio     Audio       `json:"audio,omitempty"`
-	Document  Document    `json:"document,omitempty"`
+	Id string
+	// set when the text was resent as plain text after a parse error
+	ParseFallback   bool  `json:"-"`
+	MessageId       int64 `json:"message_id"`
+	MessageThreadId int64 `json:"message_thread_id,omitempty"`
+
+	From                 *User  `json:"from,omitempty"`
+	SenderChat           *Chat  `json:"sender_chat,omitempty"`
+	SenderBoostCount     int64  `json:"sender_boost_count,omitempty"`
+	SenderBusinessBot    *User  `json:"sender_business_bot,omitempty"`
+	Date                 uint64 `json:"date"`
+	BusinessConnectionId string `json:"business_connection_id,omitempty"`
+	Chat                 Chat   `json:"chat"`
+
+	ForwardOrigin      *MessageOrigin `json:"forward_origin,omitempty"`
+	IsTopicMessage     bool           `json:"is_topic_message,omitempty"`
+	IsAutomaticForward bool           `json:"is_automatic_forward,omitempty"`
+
+	ReplyToMessage *Message   `json:"reply_to_message"`
+	Quote          *TextQuote `json:"quote,omitempty"`
+	ReplyToStory   *Story     `json:"reply_to_story,omitempty"`
+
+	ViaBot              *User  `json:"via_bot,omitempty"`
+	EditDate            uint64 `json:"edit_date,omitempty"`
+	HasProtectedContent bool   `json:"has_protected_content,omitempty"`
+	IsFromOffline       bool   `json:"is_from_offline,omitempty"`
+	MediaGroupId        string `json:"media_group_id,omitempty"`
+	AuthorSignature     string `json:"author_signature,omitempty"`
+
+	Text               string              `json:"text,omitempty"`
+	Entities           []MessageEntity     `json:"entities,omitempty"`
+	LinkPreviewOptions *LinkPreviewOptions `json:"link_preview_options,omitempty"`
+	EffectId           string              `json:"effect_id,omitempty"`
+
+	Animation *Animation  `json:"animation,omitempty"`
+	Audio     *Audio      `json:"audio,omitempty"`
+	Document  *Document   `json:"document,omitempty"`
 	Photo     []PhotoSize `json:"photo,omitempty"`
-	Video     Video       `json:"video,omitempty"`
-	VideoNote VideoNote   `json:"video_note,omitempty"`
-	Voice     Voice       `json:"voice,omitempty"`
+	Sticker   *Sticker    `json:"sticker,omitempty"`
+	Story     *Story      `json:"story,omitempty"`
+	Video     *Video      `json:"video,omitempty"`
+	VideoNote *VideoNote  `json:"video_note,omitempty"`
+	Voice     *Voice      `json:"voice,omitempty"`
+
+	Caption               string          `json:"caption,omitempty"`
+	CaptionEntities       []MessageEntity `json:"caption_entities,omitempty"`
+	ShowCaptionAboveMedia *bool           `json:"show_caption_above_media,omitempty"`
+	HasMediaSpoiler       bool            `json:"has_media_spoiler,omitempty"`
+
+	Contact  *Contact  `json:"contact,omitempty"`
+	Dice     *Dice     `json:"dice,omitempty"`
+	Poll     *Poll     `json:"poll,omitempty"`
+	Venue    *Venue    `json:"venue,omitempty"`
+	Location *Location `json:"location,omitempty"`
+
+	NewChatMembers        []User      `json:"new_chat_members"`
+	LeftChatMember        *User       `json:"left_chat_member"`
+	NewChatTitle          *string     `json:"new_chat_title"`
+	NewChatPhoto          []PhotoSize `json:"new_chat_photo,omitempty"`
+	DeleteChatPhoto       bool        `json:"delete_chat_photo,omitempty"`
+	GroupChatCreated      bool        `json:"group_chat_created,omitempty"`
+	SupergroupChatCreated bool        `json:"supergroup_chat_created,omitempty"`
+	ChannelChatCreated    bool        `json:"channel_chat_created,omitempty"`
+	MigrateToChatId       int64       `json:"migrate_to_chat_id,omitempty"`
+	MigrateFromChatId     int64       `json:"migrate_from_chat_id,omitempty"`
+	PinnedMessage         *Message    `json:"pinned_message,omitempty"`
+
+	ForumTopicCreated  *ForumTopic `json:"forum_topic_created,omitempty"`
+	ForumTopicEdited   *ForumTopic `json:"forum_topic_edited,omitempty"`
+	ForumTopicClosed   *struct{}   `json:"forum_topic_closed,omitempty"`
+	ForumTopicReopened *struct{}   `json:"forum_topic_reopened,omitempty"`
+
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+
+	// json the message was decoded from, including fields not known to this package,
+	// store Raw to archive the message losslessly, json.Marshal serializes the decoded fields
+	Raw json.RawMessage `json:"-"`
+}
+
+// https://core.telegram.org/bots/api#messageentity
+type MessageEntity struct {
+	Type          string `json:"type"`
+	Offset        int    `json:"offset"`
+	Length        int    `json:"length"`
+	Url           string `json:"url,omitempty"`
+	User          *User  `json:"user,omitempty"`
+	Language      string `json:"language,omitempty"`
+	CustomEmojiId string `json:"custom_emoji_id,omitempty"`
+}
+
+// https://core.telegram.org/bots/api#messageorigin
+type MessageOrigin struct {
+	Type string `json:"type"`
+	Date uint64 `json:"date"`
+
+	SenderUser      *User  `json:"sender_user,omitempty"`
+	SenderUserName  string `json:"sender_user_name,omitempty"`
+	SenderChat      *Chat  `json:"sender_chat,omitempty"`
+	Chat            *Chat  `json:"chat,omitempty"`
+	MessageId       int64  `json:"message_id,omitempty"`
+	AuthorSignature string `json:"author_signature,omitempty"`
+}
+
+// https://core.telegram.org/bots/api#textquote
+type TextQuote struct {
+	Text     string          `json:"text"`
+	Entities []MessageEntity `json:"entities,omitempty"`
+	Position int             `json:"position"`
+	IsManual bool            `json:"is_manual,omitempty"`
+}
+
+// https://core.telegram.org/bots/api#story
+type Story struct {
+	Chat Chat  `json:"chat"`
+	Id   int64 `json:"id"`
+}
+
+// https://core.telegram.org/bots/api#forumtopiccreated
+type ForumTopic struct {
+	Name              string `json:"name"`
+	IconColor         int64  `json:"icon_color,omitempty"`
+	IconCustomEmojiId string `json:"icon_custom_emoji_id,omitempty"`
+}
 
-	Caption               string `json:"caption,omitempty"`
-	ShowCaptionAboveMedia *bool  `json:"show_caption_above_media,omitempty"`
+// https://core.telegram.org/bots/api#inlinekeyboardmarkup
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
 
-	Location Location `json:"location,omitempty"`
+// https://core.telegram.org/bots/api#inlinekeyboardbutton
+type InlineKeyboardButton struct {
+	Text                         string          `json:"text"`
+	Url                          string          `json:"url,omitempty"`
+	CallbackData                 string          `json:"callback_data,omitempty"`
+	WebApp                       *WebAppInfo     `json:"web_app,omitempty"`
+	SwitchInlineQuery            *string         `json:"switch_inline_query,omitempty"`
+	SwitchInlineQueryCurrentChat *string         `json:"switch_inline_query_current_chat,omitempty"`
+	CopyText                     *CopyTextButton `json:"copy_text,omitempty"`
+	Pay                          bool            `json:"pay,omitempty"`
+}
 
-	NewChatMembers []User `json:"new_chat_members"`
-	LeftChatMember *User  `json:"left_chat_member"`
+// https://core.telegram.org/bots/api#webappinfo
+type WebAppInfo struct {
+	Url string `json:"url"`
+}
 
-	NewChatTitle *string `json:"new_chat_title"`
+// https://core.telegram.org/bots/api#copytextbutton
+type CopyTextButton struct {
+	Text string `json:"text"`
 }
 
+// https://core.telegram.org/bots/api#user
 type User struct {
-	Id        int64  `json:"id"`
-	IsBot     bool   `json:"is_bot"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Username  string `json:"username"`
+	Id           int64  `json:"id"`
+	IsBot        bool   `json:"is_bot"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code,omitempty"`
+	IsPremium    bool   `json:"is_premium,omitempty"`
+
+	AddedToAttachmentMenu bool `json:"added_to_attachment_menu,omitempty"`
 }
 
 // https://core.telegram.org/bots/api#chat
@@ -183,6 +335,7 @@ type Chat struct {
 	Username   string `json:"username"`
 	FirstName  string `json:"first_name"`
 	LastName   string `json:"last_name"`
+	IsForum    bool   `json:"is_forum,omitempty"`
 	InviteLink string `json:"invite_link"`
 }
 
@@ -216,11 +369,18 @@ type LinkPreviewOptions struct {
 type SendMessageRequest struct {
 	// https://core.telegram.org/bots/api#sendmessage
 
-	ChatId           string `json:"chat_id"`
-	MessageId        int64  `json:"message_id"`
-	ReplyToMessageId int64  `json:"reply_to_message_id"`
-	Text             string `json:"text"`
-	ParseMode        string `json:"parse_mode,omitempty"`
+	ChatId           string          `json:"chat_id"`
+	MessageId        int64           `json:"message_id"`
+	ReplyToMessageId int64           `json:"reply_to_message_id"`
+	Text             string          `json:"text"`
+	ParseMode        string          `json:"parse_mode,omitempty"`
+	Entities         []MessageEntity `json:"entities,omitempty"`
+
+	// when set, replaces Text and ParseMode,
+	// the only way to send MarkdownV2 with plain text guaranteed to be escaped
+	Markdown Markdown `json:"-"`
+	// on a parse error resend Text stripped of formatting
+	ParseFallback bool `json:"-"`
 
 	DisableNotification bool `json:"disable_notification,omitempty"`
 
@@ -237,26 +397,37 @@ func SendMessage(req SendMessageRequest) (msg *Message, err error) {
 	// https://core.telegram.org/bots/api#sendmessage
 
 	perr(F("DEBUG SendMessage %#v", req))
-	if req.ParseMode == "" {
-		req.ParseMode = ParseMode
+	if req.Markdown.s != "" {
+		req.Text, req.ParseMode = req.Markdown.s, ParseModeMarkdownV2
 	}
-	reqjson, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
+	if req.ParseMode == "" && req.Entities == nil {
+		req.ParseMode = ParseMode
 	}
 
 	requrl := F("%s/bot%s/sendMessage", ApiUrl, ApiToken)
-	var tgresp MessageResponse
 
-	if err := postJson(requrl, bytes.NewBuffer(reqjson), &tgresp); err != nil {
+	var tgresp MessageResponse
+	fallback, err := withParseFallback(req.ParseFallback, &req.ParseMode, &req.Text, &req.Entities, func() error {
+		tgresp = MessageResponse{}
+		reqjson, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		if err := postJson(requrl, bytes.NewBuffer(reqjson), &tgresp); err != nil {
+			return err
+		}
+		if !tgresp.Ok {
+			return fmt.Errorf("sendMessage %s", tgresp.Description)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	if !tgresp.Ok {
-		return nil, fmt.Errorf("sendMessage %s", tgresp.Description)
-	}
 
 	msg = tgresp.Result
 	msg.Id = F("%d", msg.MessageId)
+	msg.ParseFallback = fallback
 
 	return msg, nil
 }
@@ -267,8 +438,15 @@ type EditMessageTextRequest struct {
 	ChatId    string `json:"chat_id"`
 	MessageId int64  `json:"message_id"`
 
-	Text      string `json:"text"`
-	ParseMode string `json:"parse_mode,omitempty"`
+	Text      string          `json:"text"`
+	ParseMode string          `json:"parse_mode,omitempty"`
+	Entities  []MessageEntity `json:"entities,omitempty"`
+
+	// when set, replaces Text and ParseMode,
+	// the only way to send MarkdownV2 with plain text guaranteed to be escaped
+	Markdown Markdown `json:"-"`
+	// on a parse error resend Text stripped of formatting
+	ParseFallback bool `json:"-"`
 
 	LinkPreviewOptions LinkPreviewOptions `json:"link_preview_options,omitempty"`
 }
@@ -277,26 +455,37 @@ func EditMessageText(req EditMessageTextRequest) (msg *Message, err error) {
 	// https://core.telegram.org/bots/api#editmessagetext
 
 	perr(F("DEBUG EditMessageText %#v", req))
-	if req.ParseMode == "" {
-		req.ParseMode = ParseMode
+	if req.Markdown.s != "" {
+		req.Text, req.ParseMode = req.Markdown.s, ParseModeMarkdownV2
 	}
-	reqjson, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
+	if req.ParseMode == "" && req.Entities == nil {
+		req.ParseMode = ParseMode
 	}
 
 	requrl := F("%s/bot%s/editMessageText", ApiUrl, ApiToken)
-	var tgresp MessageResponse
 
-	if err := postJson(requrl, bytes.NewBuffer(reqjson), &tgresp); err != nil {
+	var tgresp MessageResponse
+	fallback, err := withParseFallback(req.ParseFallback, &req.ParseMode, &req.Text, &req.Entities, func() error {
+		tgresp = MessageResponse{}
+		reqjson, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		if err := postJson(requrl, bytes.NewBuffer(reqjson), &tgresp); err != nil {
+			return err
+		}
+		if !tgresp.Ok {
+			return fmt.Errorf("editMessageText %s", tgresp.Description)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	if !tgresp.Ok {
-		return nil, fmt.Errorf("editMessageText %s", tgresp.Description)
-	}
 
 	msg = tgresp.Result
 	msg.Id = F("%d", msg.MessageId)
+	msg.ParseFallback = fallback
 
 	return msg, nil
 }
@@ -351,133 +540,937 @@ type PhotoSize struct {
 	FileSize     int64  `json:"file_size"`
 }
 
-type SendPhotoFileRequest struct {
-	ChatId   string
-	FileName string
-	Photo    io.Reader
+type SendPhotoFileRequest struct {
+	ChatId          string
+	FileName        string
+	Photo           io.Reader
+	Caption         string
+	ParseMode       string
+	CaptionEntities []MessageEntity
+
+	// verify the photo against the limits before uploading failing with *PhotoError
+	Check bool
+	// re-encode the photo to fit the limits instead of failing with *PhotoError, implies Check
+	Downscale bool
+
+	// on a parse error resend Caption stripped of formatting,
+	// Photo is read into memory for the resend unless it is an io.ReadSeeker
+	ParseFallback bool
+}
+
+func SendPhotoFile(req SendPhotoFileRequest) (msg *Message, err error) {
+	// https://core.telegram.org/bots/api#sendphoto
+
+	perr(F("DEBUG SendPhotoFile %#v", req))
+
+	if req.Photo == nil {
+		return nil, fmt.Errorf("Photo is <nil>")
+	}
+
+	cachekey, fileid, photo, err := uploadCacheGet("photo", req.Photo)
+	if err != nil {
+		return nil, fmt.Errorf("uploadCacheGet %v", err)
+	}
+	req.Photo = photo
+	if fileid != "" {
+		if msg, err := SendPhoto(SendPhotoRequest{
+			ChatId:          req.ChatId,
+			Photo:           fileid,
+			Caption:         req.Caption,
+			ParseMode:       req.ParseMode,
+			CaptionEntities: fileCaptionEntities(req.ParseMode, req.CaptionEntities),
+			ParseFallback:   req.ParseFallback,
+		}); err != nil {
+			perr(F("ERROR SendPhotoFile cached file_id %v", err))
+		} else {
+			return msg, nil
+		}
+	}
+
+	if req.Check || req.Downscale {
+		data, err := io.ReadAll(req.Photo)
+		if err != nil {
+			return nil, fmt.Errorf("ReadAll photo %v", err)
+		}
+		if _, _, err := CheckPhoto(data); err != nil {
+			var photoerr *PhotoError
+			if !errors.As(err, &photoerr) || !req.Downscale || photoerr.DecodeErr != nil || photoerr.AspectRatioExceeded {
+				return nil, err
+			} else if data, err = DownscalePhoto(data); err != nil {
+				return nil, fmt.Errorf("DownscalePhoto %w", err)
+			}
+		}
+		req.Photo = bytes.NewReader(data)
+	}
+
+	rereadPhoto, err := rereader(req.Photo, req.ParseFallback)
+	if err != nil {
+		return nil, fmt.Errorf("rereader photo %v", err)
+	}
+
+	var tgresp MessageResponse
+	fallback, err := withParseFallback(req.ParseFallback, &req.ParseMode, &req.Caption, &req.CaptionEntities, func() error {
+		tgresp = MessageResponse{}
+
+		var mpartBuf bytes.Buffer
+		mpart := multipart.NewWriter(&mpartBuf)
+
+		if err := mpart.WriteField("chat_id", req.ChatId); err != nil {
+			return fmt.Errorf("WriteField chat_id %v", err)
+		}
+
+		if req.Caption != "" {
+			if err := mpart.WriteField("caption", req.Caption); err != nil {
+				return fmt.Errorf("WriteField caption %v", err)
+			}
+		}
+
+		if req.ParseMode != "" {
+			if err := mpart.WriteField("parse_mode", req.ParseMode); err != nil {
+				return fmt.Errorf("WriteField parse_mode %v", err)
+			}
+		}
+
+		if err := writeEntitiesField(mpart, "caption_entities", req.CaptionEntities); err != nil {
+			return fmt.Errorf("WriteField caption_entities %v", err)
+		}
+
+		photo, err := rereadPhoto()
+		if err != nil {
+			return fmt.Errorf("photo %v", err)
+		}
+		if w, err := mpart.CreateFormFile("photo", req.FileName); err != nil {
+			return fmt.Errorf("CreateFormFile photo %v", err)
+		} else if _, err := io.Copy(w, photo); err != nil {
+			return fmt.Errorf("Copy photo %v", err)
+		}
+
+		if err := mpart.Close(); err != nil {
+			return fmt.Errorf("multipartWriter.Close %v", err)
+		}
+
+		resp, err := HttpClient.Post(
+			F("%s/bot%s/sendPhoto", ApiUrl, ApiToken),
+			mpart.FormDataContentType(),
+			&mpartBuf,
+		)
+		if err != nil {
+			return fmt.Errorf("Post %v", err)
+		}
+		defer resp.Body.Close()
+
+		if err := json.NewDecoder(resp.Body).Decode(&tgresp); err != nil {
+			return fmt.Errorf("Decode %v", err)
+		}
+		if !tgresp.Ok {
+			return fmt.Errorf("sendPhoto %s", tgresp.Description)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg = tgresp.Result
+	msg.Id = F("%d", msg.MessageId)
+	msg.ParseFallback = fallback
+
+	if len(msg.Photo) == 0 {
+		return nil, fmt.Errorf("sendPhoto Photo array empty")
+	}
+
+	uploadCachePut(cachekey, msg.Photo[len(msg.Photo)-1].FileId)
+
+	return msg, nil
+}
+
+type SendPhotoRequest struct {
+	ChatId          string          `json:"chat_id"`
+	Photo           string          `json:"photo"`
+	Caption         string          `json:"caption"`
+	ParseMode       string          `json:"parse_mode,omitempty"`
+	CaptionEntities []MessageEntity `json:"caption_entities,omitempty"`
+
+	// on a parse error resend Caption stripped of formatting
+	ParseFallback bool `json:"-"`
+}
+
+func SendPhoto(req SendPhotoRequest) (msg *Message, err error) {
+	// https://core.telegram.org/bots/api#sendphoto
+
+	perr(F("DEBUG SendPhoto %#v", req))
+
+	if req.ParseMode == "" && req.CaptionEntities == nil {
+		req.ParseMode = ParseMode
+	}
+
+	requrl := F("%s/bot%s/sendPhoto", ApiUrl, ApiToken)
+
+	var tgresp MessageResponse
+	fallback, err := withParseFallback(req.ParseFallback, &req.ParseMode, &req.Caption, &req.CaptionEntities, func() error {
+		tgresp = MessageResponse{}
+		reqjson, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		if err := postJson(requrl, bytes.NewBuffer(reqjson), &tgresp); err != nil {
+			return err
+		}
+		if !tgresp.Ok {
+			return fmt.Errorf("sendPhoto %s", tgresp.Description)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg = tgresp.Result
+	msg.Id = F("%d", msg.MessageId)
+	msg.ParseFallback = fallback
+
+	return msg, nil
+}
+
+type Audio struct {
+	FileId       string    `json:"file_id"`
+	FileUniqueId string    `json:"file_unique_id"`
+	Duration     int64     `json:"duration"`
+	Performer    string    `json:"performer"`
+	Title        string    `json:"title"`
+	MimeType     string    `json:"mime_type"`
+	FileSize     int64     `json:"file_size"`
+	Thumb        PhotoSize `json:"thumbnail"`
+}
+
+type SendAudioFileRequest struct {
+	ChatId          string
+	Caption         string
+	ParseMode       string
+	CaptionEntities []MessageEntity
+	Performer       string
+	Title           string
+	Duration        time.Duration
+	Audio           io.Reader
+	Thumb           io.Reader
+
+	// fill empty Performer, Title, Duration and Thumb from the audio tags and cover art,
+	// Audio has to be an io.ReadSeeker
+	ReadTags bool
+
+	// on a parse error resend Caption stripped of formatting,
+	// Audio is read into memory for the resend unless it is an io.ReadSeeker
+	ParseFallback bool
+}
+
+func SendAudioFile(req SendAudioFileRequest) (msg *Message, err error) {
+	// https://core.telegram.org/bots/api#sending-files
+
+	perr(F("DEBUG SendAudioFile %#v", req))
+
+	if req.Audio == nil {
+		return nil, fmt.Errorf("Audio is <nil>")
+	}
+
+	if req.ReadTags {
+		rs, ok := req.Audio.(io.ReadSeeker)
+		if !ok {
+			return nil, fmt.Errorf("ReadTags requires Audio to be an io.ReadSeeker")
+		}
+		if err := readAudioTags(&req, rs); err != nil {
+			perr(F("DEBUG SendAudioFile readAudioTags %v", err))
+		}
+	}
+
+	cachekey, fileid, audio, err := uploadCacheGet("audio", req.Audio)
+	if err != nil {
+		return nil, fmt.Errorf("uploadCacheGet %v", err)
+	}
+	req.Audio = audio
+	if fileid != "" {
+		// the thumbnail is only sent with the upload
+		if msg, err := SendAudio(SendAudioRequest{
+			ChatId:          req.ChatId,
+			Audio:           fileid,
+			Caption:         req.Caption,
+			ParseMode:       req.ParseMode,
+			CaptionEntities: fileCaptionEntities(req.ParseMode, req.CaptionEntities),
+			Performer:       req.Performer,
+			Title:           req.Title,
+			Duration:        int64(req.Duration.Seconds()),
+			ParseFallback:   req.ParseFallback,
+		}); err != nil {
+			perr(F("ERROR SendAudioFile cached file_id %v", err))
+		} else {
+			return msg, nil
+		}
+	}
+
+	rereadAudio, err := rereader(req.Audio, req.ParseFallback)
+	if err != nil {
+		return nil, fmt.Errorf("rereader audio %v", err)
+	}
+	var rereadThumb func() (io.Reader, error)
+	if req.Thumb != nil {
+		r, err := thumbnail(req.Thumb)
+		if err != nil {
+			return nil, fmt.Errorf("thumbnail %v", err)
+		}
+		if rereadThumb, err = rereader(r, req.ParseFallback); err != nil {
+			return nil, fmt.Errorf("rereader thumbnail %v", err)
+		}
+	}
+
+	filename := safestring(req.Performer+"."+req.Title) + "..audio"
+
+	var tgresp MessageResponse
+	fallback, err := withParseFallback(req.ParseFallback, &req.ParseMode, &req.Caption, &req.CaptionEntities, func() error {
+		tgresp = MessageResponse{}
+
+		var mpartBuf bytes.Buffer
+		mpart := multipart.NewWriter(&mpartBuf)
+
+		if err := mpart.WriteField("chat_id", req.ChatId); err != nil {
+			return fmt.Errorf("WriteField chat_id %v", err)
+		}
+
+		if err := mpart.WriteField("caption", req.Caption); err != nil {
+			return fmt.Errorf("WriteField caption %v", err)
+		}
+
+		if req.ParseMode != "" {
+			if err := mpart.WriteField("parse_mode", req.ParseMode); err != nil {
+				return fmt.Errorf("WriteField parse_mode %v", err)
+			}
+		}
+
+		if err := writeEntitiesField(mpart, "caption_entities", req.CaptionEntities); err != nil {
+			return fmt.Errorf("WriteField caption_entities %v", err)
+		}
+
+		if err := mpart.WriteField("performer", req.Performer); err != nil {
+			return fmt.Errorf("WriteField performer %v", err)
+		}
+
+		if err := mpart.WriteField("title", req.Title); err != nil {
+			return fmt.Errorf("WriteField title %v", err)
+		}
+
+		if err := mpart.WriteField("duration", strconv.Itoa(int(req.Duration.Seconds()))); err != nil {
+			return fmt.Errorf("WriteField duration %v", err)
+		}
+
+		audio, err := rereadAudio()
+		if err != nil {
+			return fmt.Errorf("audio %v", err)
+		}
+		if w, err := mpart.CreateFormFile("audio", filename); err != nil {
+			return fmt.Errorf("CreateFormFile audio %v", err)
+		} else if _, err := io.Copy(w, audio); err != nil {
+			return fmt.Errorf("Copy audio %v", err)
+		}
+
+		if rereadThumb != nil {
+			thumb, err := rereadThumb()
+			if err != nil {
+				return fmt.Errorf("thumbnail %v", err)
+			}
+			if w, err := mpart.CreateFormFile("thumbnail", filename); err != nil {
+				return fmt.Errorf("CreateFormFile thumbnail %v", err)
+			} else if _, err := io.Copy(w, thumb); err != nil {
+				return fmt.Errorf("Copy thumbnail %v", err)
+			}
+		}
+
+		if err := mpart.Close(); err != nil {
+			return fmt.Errorf("multipart.Writer.Close %v", err)
+		}
+
+		resp, err := HttpClient.Post(
+			F("%s/bot%s/sendAudio", ApiUrl, ApiToken),
+			mpart.FormDataContentType(),
+			&mpartBuf,
+		)
+		if err != nil {
+			return fmt.Errorf("Post %v", err)
+		}
+		defer resp.Body.Close()
+
+		if err := json.NewDecoder(resp.Body).Decode(&tgresp); err != nil {
+			return fmt.Errorf("Decode %v", err)
+		}
+		if !tgresp.Ok {
+			return fmt.Errorf("sendAudio %s", tgresp.Description)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg = tgresp.Result
+	msg.Id = F("%d", msg.MessageId)
+	msg.ParseFallback = fallback
+
+	perr(F("DEBUG sendAudio response Result %#v", tgresp.Result))
+	perr(F("DEBUG sendAudio response Audio %#v", msg.Audio))
+
+	if msg.Audio == nil || msg.Audio.FileId == "" {
+		return nil, fmt.Errorf("sendAudio Audio.FileId empty")
+	}
+
+	uploadCachePut(cachekey, msg.Audio.FileId)
+
+	return msg, nil
+}
+
+// readAudioTags fills empty Performer, Title, Duration and Thumb of req from the audio tags
+// converting the cover art to a thumbnail and rewinds the audio back to where it was.
+func readAudioTags(req *SendAudioFileRequest, rs io.ReadSeeker) error {
+	pos, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("Seek %w", err)
+	}
+	tags, tagserr := ReadAudioTags(rs)
+	if _, err := rs.Seek(pos, io.SeekStart); err != nil {
+		return fmt.Errorf("Seek %w", err)
+	}
+	if tagserr != nil {
+		return fmt.Errorf("ReadAudioTags %w", tagserr)
+	}
+
+	perr(F("DEBUG readAudioTags Performer==%q Title==%q Duration==%v Cover size <%d>", tags.Performer, tags.Title, tags.Duration, len(tags.Cover)))
+
+	if req.Performer == "" {
+		req.Performer = tags.Performer
+	}
+	if req.Title == "" {
+		req.Title = tags.Title
+	}
+	if req.Duration == 0 {
+		req.Duration = tags.Duration
+	}
+	if req.Thumb == nil && len(tags.Cover) > 0 {
+		// the cover art is often too large for a thumbnail
+		if thumb, err := Thumbnail(bytes.NewReader(tags.Cover)); err != nil {
+			perr(F("DEBUG readAudioTags Thumbnail %v", err))
+		} else {
+			req.Thumb = bytes.NewReader(thumb)
+		}
+	}
+
+	return nil
+}
+
+type SendAudioRequest struct {
+	ChatId          string          `json:"chat_id"`
+	Audio           string          `json:"audio"`
+	Caption         string          `json:"caption"`
+	ParseMode       string          `json:"parse_mode,omitempty"`
+	CaptionEntities []MessageEntity `json:"caption_entities,omitempty"`
+	Performer       string          `json:"performer,omitempty"`
+	Title           string          `json:"title,omitempty"`
+	Duration        int64           `json:"duration,omitempty"`
+
+	// on a parse error resend Caption stripped of formatting
+	ParseFallback bool `json:"-"`
+}
+
+func SendAudio(req SendAudioRequest) (msg *Message, err error) {
+	// https://core.telegram.org/bots/API#sendaudio
+
+	perr(F("DEBUG SendAudio %#v", req))
+
+	if req.ParseMode == "" && req.CaptionEntities == nil {
+		req.ParseMode = ParseMode
+	}
+
+	requrl := F("%s/bot%s/sendAudio", ApiUrl, ApiToken)
+
+	var tgresp MessageResponse
+	fallback, err := withParseFallback(req.ParseFallback, &req.ParseMode, &req.Caption, &req.CaptionEntities, func() error {
+		tgresp = MessageResponse{}
+		reqjson, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		if err := postJson(requrl, bytes.NewBuffer(reqjson), &tgresp); err != nil {
+			return err
+		}
+		if !tgresp.Ok {
+			return fmt.Errorf("sendAudio %s", tgresp.Description)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg = tgresp.Result
+	msg.Id = F("%d", msg.MessageId)
+	msg.ParseFallback = fallback
+
+	return msg, nil
+}
+
+type SendVideoFileRequest struct {
+	ChatId            string
+	Caption           string
+	ParseMode         string
+	CaptionEntities   []MessageEntity
+	Video             io.Reader
+	Width, Height     int
+	Duration          time.Duration
+	SupportsStreaming bool
+	Thumb             io.Reader
+
+	// fill empty Width, Height and Duration and SupportsStreaming from the mp4 metadata,
+	// Video has to be an io.ReadSeeker
+	Probe bool
+
+	// on a parse error resend Caption stripped of formatting,
+	// Video is read into memory for the resend unless it is an io.ReadSeeker
+	ParseFallback bool
+}
+
+func SendVideoFile(req SendVideoFileRequest) (msg *Message, err error) {
+	// https://core.telegram.org/bots/API#sendvideo
+
+	perr(F("DEBUG SendVideoFile %#v", req))
+
+	if req.Video == nil {
+		return nil, fmt.Errorf("Video is <nil>")
+	}
+
+	if req.Probe {
+		rs, ok := req.Video.(io.ReadSeeker)
+		if !ok {
+			return nil, fmt.Errorf("Probe requires Video to be an io.ReadSeeker")
+		}
+		if err := probeVideo(&req, rs); err != nil {
+			perr(F("DEBUG SendVideoFile probeVideo %v", err))
+		}
+	}
+
+	cachekey, fileid, video, err := uploadCacheGet("video", req.Video)
+	if err != nil {
+		return nil, fmt.Errorf("uploadCacheGet %w", err)
+	}
+	req.Video = video
+	if fileid != "" {
+		// the thumbnail is only sent with the upload
+		if msg, err := SendVideo(SendVideoRequest{
+			ChatId:            req.ChatId,
+			Video:             fileid,
+			Caption:           req.Caption,
+			ParseMode:         req.ParseMode,
+			CaptionEntities:   fileCaptionEntities(req.ParseMode, req.CaptionEntities),
+			Width:             req.Width,
+			Height:            req.Height,
+			Duration:          int64(req.Duration.Seconds()),
+			SupportsStreaming: req.SupportsStreaming,
+			ParseFallback:     req.ParseFallback,
+		}); err != nil {
+			perr(F("ERROR SendVideoFile cached file_id %v", err))
+		} else {
+			return msg, nil
+		}
+	}
+
+	rereadVideo, err := rereader(req.Video, req.ParseFallback)
+	if err != nil {
+		return nil, fmt.Errorf("rereader video %v", err)
+	}
+	var rereadThumb func() (io.Reader, error)
+	if req.Thumb != nil {
+		r, err := thumbnail(req.Thumb)
+		if err != nil {
+			return nil, fmt.Errorf("thumbnail %v", err)
+		}
+		if rereadThumb, err = rereader(r, req.ParseFallback); err != nil {
+			return nil, fmt.Errorf("rereader thumbnail %v", err)
+		}
+	}
+
+	filename := safestring(req.Caption) + "..video"
+
+	var tgresp MessageResponse
+	fallback, err := withParseFallback(req.ParseFallback, &req.ParseMode, &req.Caption, &req.CaptionEntities, func() error {
+		tgresp = MessageResponse{}
+
+		piper, pipew := io.Pipe()
+		mpartw := multipart.NewWriter(pipew)
+
+		var mparterr error
+		go func(err error) {
+			defer func() {
+				if mparterr != nil {
+					perr(F("ERROR mparterr %v", err))
+				}
+			}()
+
+			var formw io.Writer
+
+			defer pipew.Close()
+
+			err = mpartw.WriteField("chat_id", req.ChatId)
+			if err != nil {
+				err = fmt.Errorf("WriteField chat_id %w", err)
+				return
+			}
+
+			err = mpartw.WriteField("caption", req.Caption)
+			if err != nil {
+				err = fmt.Errorf("WriteField caption %w", err)
+				return
+			}
+
+			if req.ParseMode != "" {
+				err = mpartw.WriteField("parse_mode", req.ParseMode)
+				if err != nil {
+					err = fmt.Errorf("WriteField parse_mode %w", err)
+					return
+				}
+			}
+
+			err = writeEntitiesField(mpartw, "caption_entities", req.CaptionEntities)
+			if err != nil {
+				err = fmt.Errorf("WriteField caption_entities %w", err)
+				return
+			}
+
+			err = mpartw.WriteField("width", strconv.Itoa(req.Width))
+			if err != nil {
+				err = fmt.Errorf("WriteField width %w", err)
+				return
+			}
+
+			err = mpartw.WriteField("height", strconv.Itoa(req.Height))
+			if err != nil {
+				err = fmt.Errorf("WriteField height %w", err)
+				return
+			}
+
+			err = mpartw.WriteField("duration", strconv.Itoa(int(req.Duration.Seconds())))
+			if err != nil {
+				err = fmt.Errorf("CreateFormField duration %w", err)
+				return
+			}
+
+			if req.SupportsStreaming {
+				err = mpartw.WriteField("supports_streaming", "true")
+				if err != nil {
+					err = fmt.Errorf("WriteField supports_streaming %w", err)
+					return
+				}
+			}
+
+			var video io.Reader
+			video, err = rereadVideo()
+			if err != nil {
+				err = fmt.Errorf("video %w", err)
+				return
+			}
+			formw, err = mpartw.CreateFormFile("video", filename)
+			if err != nil {
+				err = fmt.Errorf("CreateFormFile video %w", err)
+				return
+			}
+			_, err = io.Copy(formw, video)
+			if err != nil {
+				err = fmt.Errorf("Copy req.Video %w", err)
+				return
+			}
+
+			if rereadThumb != nil {
+				var thumb io.Reader
+				thumb, err = rereadThumb()
+				if err != nil {
+					err = fmt.Errorf("thumbnail %w", err)
+					return
+				}
+				formw, err = mpartw.CreateFormFile("thumbnail", filename)
+				if err != nil {
+					err = fmt.Errorf("CreateFormFile thumbnail %w", err)
+					return
+				}
+				_, err = io.Copy(formw, thumb)
+				if err != nil {
+					err = fmt.Errorf("Copy thumbnail %w", err)
+					return
+				}
+			}
+
+			if err := mpartw.Close(); err != nil {
+				err = fmt.Errorf("multipart.Writer.Close %w", err)
+				return
+			}
+		}(mparterr)
+
+		resp, err := HttpClient.Post(
+			F("%s/bot%s/sendVideo", ApiUrl, ApiToken),
+			mpartw.FormDataContentType(),
+			piper,
+		)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if mparterr != nil {
+			return err
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&tgresp); err != nil {
+			return fmt.Errorf("Decode %w", err)
+		}
+		if !tgresp.Ok {
+			return fmt.Errorf("sendVideo %s", tgresp.Description)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg = tgresp.Result
+	msg.Id = F("%d", msg.MessageId)
+	msg.ParseFallback = fallback
+
+	if msg.Video == nil || msg.Video.FileId == "" {
+		return nil, fmt.Errorf("sendVideo Video.FileId empty")
+	}
+
+	uploadCachePut(cachekey, msg.Video.FileId)
+
+	return msg, nil
+}
+
+type SendVideoRequest struct {
+	ChatId            string          `json:"chat_id"`
+	Video             string          `json:"video"`
+	Caption           string          `json:"caption"`
+	ParseMode         string          `json:"parse_mode,omitempty"`
+	CaptionEntities   []MessageEntity `json:"caption_entities,omitempty"`
+	Width             int             `json:"width,omitempty"`
+	Height            int             `json:"height,omitempty"`
+	Duration          int64           `json:"duration,omitempty"`
+	SupportsStreaming bool            `json:"supports_streaming,omitempty"`
+
+	// on a parse error resend Caption stripped of formatting
+	ParseFallback bool `json:"-"`
+}
+
+func SendVideo(req SendVideoRequest) (msg *Message, err error) {
+	// https://core.telegram.org/bots/api#sendvideo
+
+	perr(F("DEBUG SendVideo %#v", req))
+
+	if req.ParseMode == "" && req.CaptionEntities == nil {
+		req.ParseMode = ParseMode
+	}
+
+	requrl := F("%s/bot%s/sendVideo", ApiUrl, ApiToken)
+
+	var tgresp MessageResponse
+	fallback, err := withParseFallback(req.ParseFallback, &req.ParseMode, &req.Caption, &req.CaptionEntities, func() error {
+		tgresp = MessageResponse{}
+		reqjson, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		if err := postJson(requrl, bytes.NewBuffer(reqjson), &tgresp); err != nil {
+			return err
+		}
+		if !tgresp.Ok {
+			return fmt.Errorf("sendVideo %s", tgresp.Description)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg = tgresp.Result
+	msg.Id = F("%d", msg.MessageId)
+	msg.ParseFallback = fallback
+
+	return msg, nil
 }
 
-func SendPhotoFile(req SendPhotoFileRequest) (msg *Message, err error) {
-	// https://core.telegram.org/bots/api#sendphoto
+type SendVoiceFileRequest struct {
+	ChatId          string
+	Caption         string
+	ParseMode       string
+	CaptionEntities []MessageEntity
+	Duration        time.Duration
+	Voice           io.Reader
 
-	perr(F("DEBUG SendPhotoFile %#v", req))
+	// on a parse error resend Caption stripped of formatting,
+	// Voice is read into memory for the resend unless it is an io.ReadSeeker
+	ParseFallback bool
+}
 
-	var mpartBuf bytes.Buffer
-	mpart := multipart.NewWriter(&mpartBuf)
-	var formWr io.Writer
+func SendVoiceFile(req SendVoiceFileRequest) (msg *Message, err error) {
+	// https://core.telegram.org/bots/api#sendvoice
 
-	err = mpart.WriteField("chat_id", req.ChatId)
-	if err != nil {
-		return nil, fmt.Errorf("WriteField chat_id %v", err)
-	}
+	perr(F("DEBUG SendVoiceFile %#v", req))
 
-	formWr, err = mpart.CreateFormFile("photo", req.FileName)
-	if err != nil {
-		return nil, fmt.Errorf("CreateFormFile photo %v", err)
-	}
-	_, err = io.Copy(formWr, req.Photo)
-	if err != nil {
-		return nil, fmt.Errorf("Copy photo %v", err)
+	if req.Voice == nil {
+		return nil, fmt.Errorf("Voice is <nil>")
 	}
 
-	err = mpart.Close()
+	rereadVoice, err := rereader(req.Voice, req.ParseFallback)
 	if err != nil {
-		return nil, fmt.Errorf("multipartWriter.Close %v", err)
+		return nil, fmt.Errorf("rereader voice %v", err)
 	}
 
-	resp, err := HttpClient.Post(
-		F("%s/bot%s/sendPhoto", ApiUrl, ApiToken),
-		mpart.FormDataContentType(),
-		&mpartBuf,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("Post %v", err)
-	}
-	defer resp.Body.Close()
+	filename := safestring(req.Caption) + "..voice"
 
 	var tgresp MessageResponse
-	err = json.NewDecoder(resp.Body).Decode(&tgresp)
+	fallback, err := withParseFallback(req.ParseFallback, &req.ParseMode, &req.Caption, &req.CaptionEntities, func() error {
+		tgresp = MessageResponse{}
+
+		var mpartBuf bytes.Buffer
+		mpart := multipart.NewWriter(&mpartBuf)
+
+		if err := mpart.WriteField("chat_id", req.ChatId); err != nil {
+			return fmt.Errorf("WriteField chat_id %v", err)
+		}
+
+		if err := mpart.WriteField("caption", req.Caption); err != nil {
+			return fmt.Errorf("WriteField caption %v", err)
+		}
+
+		if req.ParseMode != "" {
+			if err := mpart.WriteField("parse_mode", req.ParseMode); err != nil {
+				return fmt.Errorf("WriteField parse_mode %v", err)
+			}
+		}
+
+		if err := writeEntitiesField(mpart, "caption_entities", req.CaptionEntities); err != nil {
+			return fmt.Errorf("WriteField caption_entities %v", err)
+		}
+
+		if err := mpart.WriteField("duration", strconv.Itoa(int(req.Duration.Seconds()))); err != nil {
+			return fmt.Errorf("WriteField duration %v", err)
+		}
+
+		voice, err := rereadVoice()
+		if err != nil {
+			return fmt.Errorf("voice %v", err)
+		}
+		if w, err := mpart.CreateFormFile("voice", filename); err != nil {
+			return fmt.Errorf("CreateFormFile voice %v", err)
+		} else if _, err := io.Copy(w, voice); err != nil {
+			return fmt.Errorf("Copy voice %v", err)
+		}
+
+		if err := mpart.Close(); err != nil {
+			return fmt.Errorf("multipart.Writer.Close %v", err)
+		}
+
+		resp, err := HttpClient.Post(
+			F("%s/bot%s/sendVoice", ApiUrl, ApiToken),
+			mpart.FormDataContentType(),
+			&mpartBuf,
+		)
+		if err != nil {
+			return fmt.Errorf("Post %v", err)
+		}
+		defer resp.Body.Close()
+
+		if err := json.NewDecoder(resp.Body).Decode(&tgresp); err != nil {
+			return fmt.Errorf("Decode %v", err)
+		}
+		if !tgresp.Ok {
+			return fmt.Errorf("sendVoice %s", tgresp.Description)
+		}
+
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("Decode %v", err)
-	}
-	if !tgresp.Ok {
-		return nil, fmt.Errorf("sendPhoto %s", tgresp.Description)
+		return nil, err
 	}
 
 	msg = tgresp.Result
 	msg.Id = F("%d", msg.MessageId)
+	msg.ParseFallback = fallback
 
-	if len(msg.Photo) == 0 {
-		return nil, fmt.Errorf("sendPhoto Photo array empty")
+	if msg.Voice == nil || msg.Voice.FileId == "" {
+		return nil, fmt.Errorf("sendVoice Voice.FileId empty")
 	}
 
 	return msg, nil
 }
 
-type SendPhotoRequest struct {
-	ChatId    string `json:"chat_id"`
-	Photo     string `json:"photo"`
-	Caption   string `json:"caption"`
-	ParseMode string `json:"parse_mode"`
+type SendVoiceRequest struct {
+	ChatId          string          `json:"chat_id"`
+	Voice           string          `json:"voice"`
+	Caption         string          `json:"caption"`
+	ParseMode       string          `json:"parse_mode,omitempty"`
+	CaptionEntities []MessageEntity `json:"caption_entities,omitempty"`
+	Duration        int64           `json:"duration,omitempty"`
+
+	// on a parse error resend Caption stripped of formatting
+	ParseFallback bool `json:"-"`
 }
 
-func SendPhoto(req SendPhotoRequest) (msg *Message, err error) {
-	// https://core.telegram.org/bots/api#sendphoto
+func SendVoice(req SendVoiceRequest) (msg *Message, err error) {
+	// https://core.telegram.org/bots/api#sendvoice
 
-	perr(F("DEBUG SendPhoto %#v", req))
+	perr(F("DEBUG SendVoice %#v", req))
 
-	if req.ParseMode == "" {
+	if req.ParseMode == "" && req.CaptionEntities == nil {
 		req.ParseMode = ParseMode
 	}
-	reqjson, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
 
-	requrl := F("%s/bot%s/sendPhoto", ApiUrl, ApiToken)
+	requrl := F("%s/bot%s/sendVoice", ApiUrl, ApiToken)
 
 	var tgresp MessageResponse
-	if err := postJson(requrl, bytes.NewBuffer(reqjson), &tgresp); err != nil {
+	fallback, err := withParseFallback(req.ParseFallback, &req.ParseMode, &req.Caption, &req.CaptionEntities, func() error {
+		tgresp = MessageResponse{}
+		reqjson, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		if err := postJson(requrl, bytes.NewBuffer(reqjson), &tgresp); err != nil {
+			return err
+		}
+		if !tgresp.Ok {
+			return fmt.Errorf("sendVoice %s", tgresp.Description)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	if !tgresp.Ok {
-		return nil, fmt.Errorf("sendPhoto %s", tgresp.Description)
-	}
 
 	msg = tgresp.Result
 	msg.Id = F("%d", msg.MessageId)
+	msg.ParseFallback = fallback
 
 	return msg, nil
 }
 
-type Audio struct {
-	FileId       string    `json:"file_id"`
-	FileUniqueId string    `json:"file_unique_id"`
-	Duration     int64     `json:"duration"`
-	Performer    string    `json:"performer"`
-	Title        string    `json:"title"`
-	MimeType     string    `json:"mime_type"`
-	FileSize     int64     `json:"file_size"`
-	Thumb        PhotoSize `json:"thumb"`
-}
-
-type SendAudioFileRequest struct {
+type SendVideoNoteFileRequest struct {
 	ChatId    string
-	Caption   string
-	Performer string
-	Title     string
+	Length    int
 	Duration  time.Duration
-	Audio     io.Reader
+	VideoNote io.Reader
 	Thumb     io.Reader
 }
 
-func SendAudioFile(req SendAudioFileRequest) (msg *Message, err error) {
-	// https://core.telegram.org/bots/api#sending-files
+func SendVideoNoteFile(req SendVideoNoteFileRequest) (msg *Message, err error) {
+	// https://core.telegram.org/bots/api#sendvideonote
 
-	perr(F("DEBUG SendAudioFile %#v", req))
+	perr(F("DEBUG SendVideoNoteFile %#v", req))
 
-	if req.Audio == nil {
-		return nil, fmt.Errorf("Audio is <nil>")
+	if req.VideoNote == nil {
+		return nil, fmt.Errorf("VideoNote is <nil>")
 	}
 
 	var mpartBuf bytes.Buffer
@@ -487,35 +1480,31 @@ func SendAudioFile(req SendAudioFileRequest) (msg *Message, err error) {
 		return nil, fmt.Errorf("WriteField chat_id %v", err)
 	}
 
-	if err := mpart.WriteField("caption", req.Caption); err != nil {
-		return nil, fmt.Errorf("WriteField caption %v", err)
-	}
-
-	if err := mpart.WriteField("performer", req.Performer); err != nil {
-		return nil, fmt.Errorf("WriteField performer %v", err)
-	}
-
-	if err := mpart.WriteField("title", req.Title); err != nil {
-		return nil, fmt.Errorf("WriteField title %v", err)
+	if req.Length > 0 {
+		if err := mpart.WriteField("length", strconv.Itoa(req.Length)); err != nil {
+			return nil, fmt.Errorf("WriteField length %v", err)
+		}
 	}
 
 	if err := mpart.WriteField("duration", strconv.Itoa(int(req.Duration.Seconds()))); err != nil {
 		return nil, fmt.Errorf("WriteField duration %v", err)
 	}
 
-	filename := safestring(req.Performer+"."+req.Title) + "..audio"
-
-	if w, err := mpart.CreateFormFile("audio", filename); err != nil {
-		return nil, fmt.Errorf("CreateFormFile audio %v", err)
-	} else if _, err := io.Copy(w, req.Audio); err != nil {
-		return nil, fmt.Errorf("Copy audio %v", err)
+	if w, err := mpart.CreateFormFile("video_note", "video_note.mp4"); err != nil {
+		return nil, fmt.Errorf("CreateFormFile video_note %v", err)
+	} else if _, err := io.Copy(w, req.VideoNote); err != nil {
+		return nil, fmt.Errorf("Copy video_note %v", err)
 	}
 
 	if req.Thumb != nil {
-		if w, err := mpart.CreateFormFile("thumb", filename); err != nil {
-			return nil, fmt.Errorf("CreateFormFile thumb %v", err)
-		} else if _, err := io.Copy(w, req.Thumb); err != nil {
-			return nil, fmt.Errorf("Copy thumb %v", err)
+		thumb, err := thumbnail(req.Thumb)
+		if err != nil {
+			return nil, fmt.Errorf("thumbnail %v", err)
+		}
+		if w, err := mpart.CreateFormFile("thumbnail", "video_note.jpg"); err != nil {
+			return nil, fmt.Errorf("CreateFormFile thumbnail %v", err)
+		} else if _, err := io.Copy(w, thumb); err != nil {
+			return nil, fmt.Errorf("Copy thumbnail %v", err)
 		}
 	}
 
@@ -524,7 +1513,7 @@ func SendAudioFile(req SendAudioFileRequest) (msg *Message, err error) {
 	}
 
 	resp, err := HttpClient.Post(
-		F("%s/bot%s/sendAudio", ApiUrl, ApiToken),
+		F("%s/bot%s/sendVideoNote", ApiUrl, ApiToken),
 		mpart.FormDataContentType(),
 		&mpartBuf,
 	)
@@ -539,51 +1528,44 @@ func SendAudioFile(req SendAudioFileRequest) (msg *Message, err error) {
 		return nil, fmt.Errorf("Decode %v", err)
 	}
 	if !tgresp.Ok {
-		return nil, fmt.Errorf("sendAudio %s", tgresp.Description)
+		return nil, fmt.Errorf("sendVideoNote %s", tgresp.Description)
 	}
 
 	msg = tgresp.Result
 	msg.Id = F("%d", msg.MessageId)
 
-	perr(F("DEBUG sendAudio response Result %#v", tgresp.Result))
-	perr(F("DEBUG sendAudio response Audio %#v", msg.Audio))
-
-	if msg.Audio.FileId == "" {
-		return nil, fmt.Errorf("sendAudio Audio.FileId empty")
+	if msg.VideoNote == nil || msg.VideoNote.FileId == "" {
+		return nil, fmt.Errorf("sendVideoNote VideoNote.FileId empty")
 	}
 
 	return msg, nil
 }
 
-type SendAudioRequest struct {
+type SendVideoNoteRequest struct {
 	ChatId    string `json:"chat_id"`
-	Audio     string `json:"audio"`
-	Caption   string `json:"caption"`
-	ParseMode string `json:"parse_mode"`
+	VideoNote string `json:"video_note"`
+	Length    int    `json:"length,omitempty"`
+	Duration  int64  `json:"duration,omitempty"`
 }
 
-func SendAudio(req SendAudioRequest) (msg *Message, err error) {
-	// https://core.telegram.org/bots/API#sendaudio
-
-	perr(F("DEBUG SendAudio %#v", req))
+func SendVideoNote(req SendVideoNoteRequest) (msg *Message, err error) {
+	// https://core.telegram.org/bots/api#sendvideonote
 
-	if req.ParseMode == "" {
-		req.ParseMode = ParseMode
-	}
+	perr(F("DEBUG SendVideoNote %#v", req))
 
 	reqjson, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
 
-	requrl := F("%s/bot%s/sendAudio", ApiUrl, ApiToken)
+	requrl := F("%s/bot%s/sendVideoNote", ApiUrl, ApiToken)
 
 	var tgresp MessageResponse
 	if err := postJson(requrl, bytes.NewBuffer(reqjson), &tgresp); err != nil {
 		return nil, err
 	}
 	if !tgresp.Ok {
-		return nil, fmt.Errorf("sendAudio %s", tgresp.Description)
+		return nil, fmt.Errorf("sendVideoNote %s", tgresp.Description)
 	}
 
 	msg = tgresp.Result
@@ -592,118 +1574,328 @@ func SendAudio(req SendAudioRequest) (msg *Message, err error) {
 	return msg, nil
 }
 
-type SendVideoFileRequest struct {
-	ChatId        string
-	Caption       string
-	Video         io.Reader
-	Width, Height int
-	Duration      time.Duration
+// https://core.telegram.org/bots/api#inputmedia
+type InputMedia interface {
+	inputMedia() (media inputMedia, file io.Reader, filename string, thumb io.Reader)
 }
 
-func SendVideoFile(req SendVideoFileRequest) (msg *Message, err error) {
-	// https://core.telegram.org/bots/API#sendvideo
+type inputMedia struct {
+	Type      string `json:"type"`
+	Media     string `json:"media"`
+	Thumbnail string `json:"thumbnail,omitempty"`
 
-	perr(F("DEBUG SendVideoFile %#v", req))
+	Caption               string          `json:"caption,omitempty"`
+	ParseMode             string          `json:"parse_mode,omitempty"`
+	CaptionEntities       []MessageEntity `json:"caption_entities,omitempty"`
+	ShowCaptionAboveMedia bool            `json:"show_caption_above_media,omitempty"`
+	HasSpoiler            bool            `json:"has_spoiler,omitempty"`
 
-	if req.Video == nil {
-		return nil, fmt.Errorf("Video is <nil>")
+	Width             int  `json:"width,omitempty"`
+	Height            int  `json:"height,omitempty"`
+	Duration          int  `json:"duration,omitempty"`
+	SupportsStreaming bool `json:"supports_streaming,omitempty"`
+
+	Performer string `json:"performer,omitempty"`
+	Title     string `json:"title,omitempty"`
+
+	DisableContentTypeDetection bool `json:"disable_content_type_detection,omitempty"`
+}
+
+type InputMediaPhoto struct {
+	// https://core.telegram.org/bots/api#inputmediaphoto
+	Media    string
+	File     io.Reader
+	FileName string
+
+	Caption         string
+	ParseMode       string
+	CaptionEntities []MessageEntity
+	HasSpoiler      bool
+}
+
+func (m InputMediaPhoto) inputMedia() (inputMedia, io.Reader, string, io.Reader) {
+	return inputMedia{
+		Type:            "photo",
+		Media:           m.Media,
+		Caption:         m.Caption,
+		ParseMode:       m.ParseMode,
+		CaptionEntities: m.CaptionEntities,
+		HasSpoiler:      m.HasSpoiler,
+	}, m.File, m.FileName, nil
+}
+
+type InputMediaVideo struct {
+	// https://core.telegram.org/bots/api#inputmediavideo
+	Media    string
+	File     io.Reader
+	FileName string
+	Thumb    io.Reader
+
+	Caption         string
+	ParseMode       string
+	CaptionEntities []MessageEntity
+	HasSpoiler      bool
+
+	Width, Height     int
+	Duration          time.Duration
+	SupportsStreaming bool
+}
+
+func (m InputMediaVideo) inputMedia() (inputMedia, io.Reader, string, io.Reader) {
+	return inputMedia{
+		Type:              "video",
+		Media:             m.Media,
+		Caption:           m.Caption,
+		ParseMode:         m.ParseMode,
+		CaptionEntities:   m.CaptionEntities,
+		HasSpoiler:        m.HasSpoiler,
+		Width:             m.Width,
+		Height:            m.Height,
+		Duration:          int(m.Duration.Seconds()),
+		SupportsStreaming: m.SupportsStreaming,
+	}, m.File, m.FileName, m.Thumb
+}
+
+type InputMediaAudio struct {
+	// https://core.telegram.org/bots/api#inputmediaaudio
+	Media    string
+	File     io.Reader
+	FileName string
+	Thumb    io.Reader
+
+	Caption         string
+	ParseMode       string
+	CaptionEntities []MessageEntity
+
+	Performer string
+	Title     string
+	Duration  time.Duration
+}
+
+func (m InputMediaAudio) inputMedia() (inputMedia, io.Reader, string, io.Reader) {
+	return inputMedia{
+		Type:            "audio",
+		Media:           m.Media,
+		Caption:         m.Caption,
+		ParseMode:       m.ParseMode,
+		CaptionEntities: m.CaptionEntities,
+		Performer:       m.Performer,
+		Title:           m.Title,
+		Duration:        int(m.Duration.Seconds()),
+	}, m.File, m.FileName, m.Thumb
+}
+
+type InputMediaDocument struct {
+	// https://core.telegram.org/bots/api#inputmediadocument
+	Media    string
+	File     io.Reader
+	FileName string
+	Thumb    io.Reader
+
+	Caption         string
+	ParseMode       string
+	CaptionEntities []MessageEntity
+
+	DisableContentTypeDetection bool
+}
+
+func (m InputMediaDocument) inputMedia() (inputMedia, io.Reader, string, io.Reader) {
+	return inputMedia{
+		Type:            "document",
+		Media:           m.Media,
+		Caption:         m.Caption,
+		ParseMode:       m.ParseMode,
+		CaptionEntities: m.CaptionEntities,
+
+		DisableContentTypeDetection: m.DisableContentTypeDetection,
+	}, m.File, m.FileName, m.Thumb
+}
+
+type SendMediaGroupRequest struct {
+	ChatId           string
+	ReplyToMessageId int64
+	Media            []InputMedia
+
+	DisableNotification bool
+
+	// on a parse error resend the captions stripped of formatting,
+	// files are read into memory for the resend unless they are io.ReadSeeker
+	ParseFallback bool
+}
+
+type MessagesResponse struct {
+	Ok          bool      `json:"ok"`
+	Description string    `json:"description"`
+	Result      []Message `json:"result"`
+}
+
+func SendMediaGroup(req SendMediaGroupRequest) (mm []Message, err error) {
+	// https://core.telegram.org/bots/api#sendmediagroup
+
+	perr(F("DEBUG SendMediaGroup %#v", req))
+
+	if len(req.Media) < MediaGroupMinLen || len(req.Media) > MediaGroupMaxLen {
+		return nil, fmt.Errorf("Media has %d items, %d to %d are allowed", len(req.Media), MediaGroupMinLen, MediaGroupMaxLen)
 	}
 
-	piper, pipew := io.Pipe()
-	mpartw := multipart.NewWriter(pipew)
+	type attachment struct {
+		name     string
+		filename string
+		r        func() (io.Reader, error)
+	}
+	var attachments []attachment
 
-	var mparterr error
-	go func(err error) {
-		defer func() {
-			if mparterr != nil {
-				perr(F("ERROR mparterr %v", err))
+	media := make([]inputMedia, len(req.Media))
+	for i, m := range req.Media {
+		im, file, filename, thumb := m.inputMedia()
+		if im.Caption != "" && im.ParseMode == "" && im.CaptionEntities == nil {
+			im.ParseMode = ParseMode
+		}
+		if file != nil {
+			name := F("file%d", i)
+			if filename == "" {
+				filename = name + ".." + im.Type
 			}
-		}()
-
-		var formw io.Writer
+			im.Media = "attach://" + name
+			r, err := rereader(file, req.ParseFallback)
+			if err != nil {
+				return nil, fmt.Errorf("rereader %s %v", name, err)
+			}
+			attachments = append(attachments, attachment{name: name, filename: filename, r: r})
+		}
+		if im.Media == "" {
+			return nil, fmt.Errorf("Media[%d] has neither Media nor File", i)
+		}
+		if thumb != nil {
+			name := F("thumbnail%d", i)
+			im.Thumbnail = "attach://" + name
+			if thumb, err = thumbnail(thumb); err != nil {
+				return nil, fmt.Errorf("thumbnail %v", err)
+			}
+			r, err := rereader(thumb, req.ParseFallback)
+			if err != nil {
+				return nil, fmt.Errorf("rereader %s %v", name, err)
+			}
+			attachments = append(attachments, attachment{name: name, filename: name, r: r})
+		}
+		media[i] = im
+	}
 
-		defer pipew.Close()
+	var tgresp MessagesResponse
+	fallback, err := withPlainFallback(req.ParseFallback, func() (stripped bool) {
+		for i := range media {
+			if media[i].ParseMode == "" {
+				continue
+			}
+			media[i].Caption, media[i].ParseMode, media[i].CaptionEntities = PlainText(media[i].Caption, media[i].ParseMode), "", nil
+			stripped = true
+		}
+		return stripped
+	}, func() error {
+		tgresp = MessagesResponse{}
 
-		err = mpartw.WriteField("chat_id", req.ChatId)
+		mediajson, err := json.Marshal(media)
 		if err != nil {
-			err = fmt.Errorf("WriteField chat_id %w", err)
-			return
+			return fmt.Errorf("json.Marshal media %v", err)
 		}
 
-		err = mpartw.WriteField("caption", req.Caption)
-		if err != nil {
-			err = fmt.Errorf("WriteField caption %w", err)
-			return
+		var mpartBuf bytes.Buffer
+		mpart := multipart.NewWriter(&mpartBuf)
+
+		if err := mpart.WriteField("chat_id", req.ChatId); err != nil {
+			return fmt.Errorf("WriteField chat_id %v", err)
 		}
 
-		err = mpartw.WriteField("width", strconv.Itoa(req.Width))
-		if err != nil {
-			err = fmt.Errorf("WriteField width %w", err)
-			return
+		if err := mpart.WriteField("media", string(mediajson)); err != nil {
+			return fmt.Errorf("WriteField media %v", err)
 		}
 
-		err = mpartw.WriteField("height", strconv.Itoa(req.Height))
-		if err != nil {
-			err = fmt.Errorf("WriteField height %w", err)
-			return
+		if req.ReplyToMessageId != 0 {
+			if err := mpart.WriteField("reply_to_message_id", F("%d", req.ReplyToMessageId)); err != nil {
+				return fmt.Errorf("WriteField reply_to_message_id %v", err)
+			}
 		}
 
-		err = mpartw.WriteField("duration", strconv.Itoa(int(req.Duration.Seconds())))
-		if err != nil {
-			err = fmt.Errorf("CreateFormField duration %w", err)
-			return
+		if req.DisableNotification {
+			if err := mpart.WriteField("disable_notification", "true"); err != nil {
+				return fmt.Errorf("WriteField disable_notification %v", err)
+			}
 		}
 
-		filename := safestring(req.Caption) + "..video"
+		for _, a := range attachments {
+			if w, err := mpart.CreateFormFile(a.name, a.filename); err != nil {
+				return fmt.Errorf("CreateFormFile %s %v", a.name, err)
+			} else if r, err := a.r(); err != nil {
+				return fmt.Errorf("%s %v", a.name, err)
+			} else if _, err := io.Copy(w, r); err != nil {
+				return fmt.Errorf("Copy %s %v", a.name, err)
+			}
+		}
 
-		formw, err = mpartw.CreateFormFile("video", filename)
-		if err != nil {
-			err = fmt.Errorf("CreateFormFile video %w", err)
-			return
+		if err := mpart.Close(); err != nil {
+			return fmt.Errorf("multipart.Writer.Close %v", err)
 		}
-		_, err = io.Copy(formw, req.Video)
+
+		resp, err := HttpClient.Post(
+			F("%s/bot%s/sendMediaGroup", ApiUrl, ApiToken),
+			mpart.FormDataContentType(),
+			&mpartBuf,
+		)
 		if err != nil {
-			err = fmt.Errorf("Copy req.Video %w", err)
-			return
+			return fmt.Errorf("Post %v", err)
 		}
+		defer resp.Body.Close()
 
-		if err := mpartw.Close(); err != nil {
-			err = fmt.Errorf("multipart.Writer.Close %w", err)
-			return
+		if err := json.NewDecoder(resp.Body).Decode(&tgresp); err != nil {
+			return fmt.Errorf("Decode %v", err)
+		}
+		if !tgresp.Ok {
+			return fmt.Errorf("sendMediaGroup %s", tgresp.Description)
 		}
-	}(mparterr)
 
-	resp, err := HttpClient.Post(
-		F("%s/bot%s/sendVideo", ApiUrl, ApiToken),
-		mpartw.FormDataContentType(),
-		piper,
-	)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if mparterr != nil {
-		return nil, err
+	mm = tgresp.Result
+	for i := range mm {
+		mm[i].Id = F("%d", mm[i].MessageId)
+		mm[i].ParseFallback = fallback
 	}
 
-	var tgresp MessageResponse
-	err = json.NewDecoder(resp.Body).Decode(&tgresp)
+	return mm, nil
+}
+
+// probeVideo fills unknown Width, Height and Duration of req from the mp4 metadata
+// and rewinds the video back to where it was.
+func probeVideo(req *SendVideoFileRequest, rs io.ReadSeeker) error {
+	pos, err := rs.Seek(0, io.SeekCurrent)
 	if err != nil {
-		return nil, fmt.Errorf("Decode %w", err)
+		return fmt.Errorf("Seek %w", err)
 	}
-	if !tgresp.Ok {
-		return nil, fmt.Errorf("sendVideo %s", tgresp.Description)
+	info, proberr := ProbeMp4(rs)
+	if _, err := rs.Seek(pos, io.SeekStart); err != nil {
+		return fmt.Errorf("Seek %w", err)
+	}
+	if proberr != nil {
+		return fmt.Errorf("ProbeMp4 %w", proberr)
 	}
 
-	msg = tgresp.Result
-	msg.Id = F("%d", msg.MessageId)
+	perr(F("DEBUG probeVideo %#v", info))
 
-	if msg.Video.FileId == "" {
-		return nil, fmt.Errorf("sendVideo Video.FileId empty")
+	if req.Width == 0 || req.Height == 0 {
+		req.Width, req.Height = info.Width, info.Height
+	}
+	if req.Duration == 0 {
+		req.Duration = info.Duration
+	}
+	if info.SupportsStreaming {
+		req.SupportsStreaming = true
 	}
 
-	return msg, nil
+	return nil
 }
 
 type DeleteMessageRequest struct {
@@ -862,6 +2054,85 @@ type ChatMemberUpdated struct {
 	ViaChatFolderInviteLink bool `json:"via_chat_folder_invite_link"`
 }
 
+// https://core.telegram.org/bots/api#chatjoinrequest
+type ChatJoinRequest struct {
+	Chat       Chat   `json:"chat"`
+	From       User   `json:"from"`
+	UserChatId int64  `json:"user_chat_id"`
+	Date       uint64 `json:"date"`
+	Bio        string `json:"bio,omitempty"`
+}
+
+// https://core.telegram.org/bots/api#reactiontype
+type ReactionType struct {
+	Type          string `json:"type"`
+	Emoji         string `json:"emoji,omitempty"`
+	CustomEmojiId string `json:"custom_emoji_id,omitempty"`
+}
+
+// https://core.telegram.org/bots/api#reactioncount
+type ReactionCount struct {
+	Type       ReactionType `json:"type"`
+	TotalCount int64        `json:"total_count"`
+}
+
+// https://core.telegram.org/bots/api#messagereactionupdated
+type MessageReactionUpdated struct {
+	Chat        Chat           `json:"chat"`
+	MessageId   int64          `json:"message_id"`
+	User        *User          `json:"user,omitempty"`
+	ActorChat   *Chat          `json:"actor_chat,omitempty"`
+	Date        uint64         `json:"date"`
+	OldReaction []ReactionType `json:"old_reaction"`
+	NewReaction []ReactionType `json:"new_reaction"`
+}
+
+// https://core.telegram.org/bots/api#messagereactioncountupdated
+type MessageReactionCountUpdated struct {
+	Chat      Chat            `json:"chat"`
+	MessageId int64           `json:"message_id"`
+	Date      uint64          `json:"date"`
+	Reactions []ReactionCount `json:"reactions"`
+}
+
+// https://core.telegram.org/bots/api#inlinequery
+type InlineQuery struct {
+	Id       string    `json:"id"`
+	From     User      `json:"from"`
+	Query    string    `json:"query"`
+	Offset   string    `json:"offset"`
+	ChatType string    `json:"chat_type,omitempty"`
+	Location *Location `json:"location,omitempty"`
+}
+
+// https://core.telegram.org/bots/api#choseninlineresult
+type ChosenInlineResult struct {
+	ResultId        string    `json:"result_id"`
+	From            User      `json:"from"`
+	Location        *Location `json:"location,omitempty"`
+	InlineMessageId string    `json:"inline_message_id,omitempty"`
+	Query           string    `json:"query"`
+}
+
+// https://core.telegram.org/bots/api#callbackquery
+type CallbackQuery struct {
+	Id              string   `json:"id"`
+	From            User     `json:"from"`
+	Message         *Message `json:"message,omitempty"`
+	InlineMessageId string   `json:"inline_message_id,omitempty"`
+	ChatInstance    string   `json:"chat_instance"`
+	Data            string   `json:"data,omitempty"`
+	GameShortName   string   `json:"game_short_name,omitempty"`
+}
+
+// https://core.telegram.org/bots/api#pollanswer
+type PollAnswer struct {
+	PollId    string  `json:"poll_id"`
+	VoterChat *Chat   `json:"voter_chat,omitempty"`
+	User      *User   `json:"user,omitempty"`
+	OptionIds []int64 `json:"option_ids"`
+}
+
 // https://core.telegram.org/bots/api#update
 type Update struct {
 	UpdateId int64 `json:"update_id"`
@@ -872,8 +2143,23 @@ type Update struct {
 	ChannelPost       Message `json:"channel_post"`
 	EditedChannelPost Message `json:"edited_channel_post"`
 
-	MyChatMember ChatMemberUpdated `json:"my_chat_member"`
-	ChatMember   ChatMemberUpdated `json:"chat_member"`
+	MessageReaction      *MessageReactionUpdated      `json:"message_reaction,omitempty"`
+	MessageReactionCount *MessageReactionCountUpdated `json:"message_reaction_count,omitempty"`
+
+	InlineQuery        *InlineQuery        `json:"inline_query,omitempty"`
+	ChosenInlineResult *ChosenInlineResult `json:"chosen_inline_result,omitempty"`
+	CallbackQuery      *CallbackQuery      `json:"callback_query,omitempty"`
+
+	Poll       *Poll       `json:"poll,omitempty"`
+	PollAnswer *PollAnswer `json:"poll_answer,omitempty"`
+
+	MyChatMember    ChatMemberUpdated `json:"my_chat_member"`
+	ChatMember      ChatMemberUpdated `json:"chat_member"`
+	ChatJoinRequest *ChatJoinRequest  `json:"chat_join_request,omitempty"`
+
+	// json the update was decoded from, including fields not known to this package,
+	// store Raw to archive the update losslessly, json.Marshal serializes the decoded fields
+	Raw json.RawMessage `json:"-"`
 }
 
 type UpdatesResponse struct {
@@ -934,19 +2220,117 @@ type Video struct {
 	Duration     int64     `json:"duration"`
 	MimeType     string    `json:"mime_type"`
 	FileSize     int64     `json:"file_size"`
-	Thumb        PhotoSize `json:"thumb"`
+	Thumb        PhotoSize `json:"thumbnail"`
 }
 
 type Document struct {
 	// https://core.telegram.org/bots/api#document
+	FileId       string    `json:"file_id"`
+	FileUniqueId string    `json:"file_unique_id"`
+	Thumb        PhotoSize `json:"thumbnail"`
+	FileName     string    `json:"file_name"`
+	MimeType     string    `json:"mime_type"`
+	FileSize     int64     `json:"file_size"`
+}
+
+type Animation struct {
+	// https://core.telegram.org/bots/api#animation
+	FileId       string    `json:"file_id"`
+	FileUniqueId string    `json:"file_unique_id"`
+	Width        int64     `json:"width"`
+	Height       int64     `json:"height"`
+	Duration     int64     `json:"duration"`
+	Thumb        PhotoSize `json:"thumbnail"`
+	FileName     string    `json:"file_name"`
+	MimeType     string    `json:"mime_type"`
+	FileSize     int64     `json:"file_size"`
+}
+
+type Sticker struct {
+	// https://core.telegram.org/bots/api#sticker
+	FileId        string    `json:"file_id"`
+	FileUniqueId  string    `json:"file_unique_id"`
+	Type          string    `json:"type"`
+	Width         int64     `json:"width"`
+	Height        int64     `json:"height"`
+	IsAnimated    bool      `json:"is_animated"`
+	IsVideo       bool      `json:"is_video"`
+	Thumb         PhotoSize `json:"thumbnail"`
+	Emoji         string    `json:"emoji"`
+	SetName       string    `json:"set_name"`
+	CustomEmojiId string    `json:"custom_emoji_id"`
+	FileSize      int64     `json:"file_size"`
+}
+
+type Contact struct {
+	// https://core.telegram.org/bots/api#contact
+	PhoneNumber string `json:"phone_number"`
+	FirstName   string `json:"first_name"`
+	LastName    string `json:"last_name"`
+	UserId      int64  `json:"user_id"`
+	Vcard       string `json:"vcard"`
+}
+
+type Dice struct {
+	// https://core.telegram.org/bots/api#dice
+	Emoji string `json:"emoji"`
+	Value int64  `json:"value"`
+}
+
+type PollOption struct {
+	// https://core.telegram.org/bots/api#polloption
+	Text         string          `json:"text"`
+	TextEntities []MessageEntity `json:"text_entities,omitempty"`
+	VoterCount   int64           `json:"voter_count"`
+}
+
+type Poll struct {
+	// https://core.telegram.org/bots/api#poll
+	Id               string          `json:"id"`
+	Question         string          `json:"question"`
+	QuestionEntities []MessageEntity `json:"question_entities,omitempty"`
+	Options          []PollOption    `json:"options"`
+	TotalVoterCount  int64           `json:"total_voter_count"`
+	IsClosed         bool            `json:"is_closed"`
+	IsAnonymous      bool            `json:"is_anonymous"`
+	Type             string          `json:"type"`
+
+	AllowsMultipleAnswers bool            `json:"allows_multiple_answers"`
+	CorrectOptionId       *int64          `json:"correct_option_id,omitempty"`
+	Explanation           string          `json:"explanation,omitempty"`
+	ExplanationEntities   []MessageEntity `json:"explanation_entities,omitempty"`
+	OpenPeriod            int64           `json:"open_period,omitempty"`
+	CloseDate             uint64          `json:"close_date,omitempty"`
+}
+
+type Venue struct {
+	// https://core.telegram.org/bots/api#venue
+	Location        Location `json:"location"`
+	Title           string   `json:"title"`
+	Address         string   `json:"address"`
+	FoursquareId    string   `json:"foursquare_id,omitempty"`
+	FoursquareType  string   `json:"foursquare_type,omitempty"`
+	GooglePlaceId   string   `json:"google_place_id,omitempty"`
+	GooglePlaceType string   `json:"google_place_type,omitempty"`
 }
 
 type VideoNote struct {
 	// https://core.telegram.org/bots/api#videonote
+	FileId       string    `json:"file_id"`
+	FileUniqueId string    `json:"file_unique_id"`
+	Length       int64     `json:"length"`
+	Duration     int64     `json:"duration"`
+	Thumb        PhotoSize `json:"thumbnail"`
+	FileSize     int64     `json:"file_size"`
 }
 
 type Voice struct {
 	// https://core.telegram.org/bots/api#voice
+	FileId       string `json:"file_id"`
+	FileUniqueId string `json:"file_unique_id"`
+	Duration     int64  `json:"duration"`
+	MimeType     string `json:"mime_type"`
+	FileSize     int64  `json:"file_size"`
 }
 
 type Location struct {
@@ -985,6 +2369,18 @@ func getJson(requrl string, result interface{}, respjson *string) (err error) {
 	return nil
 }
 
+// writeEntitiesField writes entities as a json form field, skipping empty ones.
+func writeEntitiesField(mpart *multipart.Writer, name string, entities []MessageEntity) error {
+	if len(entities) == 0 {
+		return nil
+	}
+	entitiesjson, err := json.Marshal(entities)
+	if err != nil {
+		return err
+	}
+	return mpart.WriteField(name, string(entitiesjson))
+}
+
 func postJson(requrl string, reqdata *bytes.Buffer, result interface{}) error {
 	resp, err := HttpClient.Post(
 		requrl,
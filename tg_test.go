@@ -1,7 +1,13 @@
 package tg
 
 import (
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 // https://pkg.go.dev/testing
@@ -20,9 +26,9 @@ func TestTg(t *testing.T) {
 		Link("bots api link", "https://core.telegram.org/bots/api") + NL +
 		Pre("  pre * "+NL+"  * - * "+NL+"  * formatted") + NL +
 		Quote("normal"+NL+"quote"+NL+"block"+NL+"shows"+NL+"all"+NL+"lines") + NL +
-		ExpQuote("expandable"+NL+"quote"+NL+"block"+NL+"hides"+NL+"lines"+NL+"until"+NL+"expanded")
+		ExpandQuote("expandable"+NL+"quote"+NL+"block"+NL+"hides"+NL+"lines"+NL+"until"+NL+"expanded")
 
-	log("message:" + NL + msg + NL + ":")
+	t.Log("message:" + NL + msg + NL + ":")
 
 	ApiToken = ""
 
@@ -34,7 +40,206 @@ func TestTg(t *testing.T) {
 	}); err != nil {
 		t.Error(err)
 	} else {
-		log("SendMessage result message id==%v", r.Id)
+		t.Logf("SendMessage result message id==%v", r.Id)
+	}
+
+}
+
+// testApi serves the bot api requests made during the test with handler.
+func testApi(t *testing.T, handler http.HandlerFunc) {
+
+	srv := httptest.NewServer(handler)
+	apiurl, apitoken := ApiUrl, ApiToken
+	ApiUrl, ApiToken = srv.URL, "TOKEN"
+	t.Cleanup(func() {
+		srv.Close()
+		ApiUrl, ApiToken = apiurl, apitoken
+	})
+
+}
+
+func TestSendVoice(t *testing.T) {
+
+	var form *multipart.Form
+	var req map[string]interface{}
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Error(err)
+			}
+			form = r.MultipartForm
+		} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"voice":{"file_id":"voice","duration":3}}}`))
+	})
+
+	msg, err := SendVoiceFile(SendVoiceFileRequest{ChatId: "1", Caption: "note", Duration: 3 * time.Second, Voice: strings.NewReader("ogg")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Voice.FileId != "voice" {
+		t.Errorf("Voice %+v", msg.Voice)
+	}
+	if v := form.Value; v["chat_id"][0] != "1" || v["caption"][0] != "note" || v["duration"][0] != "3" {
+		t.Errorf("form values %v", v)
+	}
+	if f := form.File["voice"]; len(f) != 1 || f[0].Size != 3 {
+		t.Errorf("form file voice %+v", f)
+	}
+
+	if _, err := SendVoice(SendVoiceRequest{ChatId: "1", Voice: "voice", Caption: "note", Duration: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if req["voice"] != "voice" || req["caption"] != "note" || req["duration"] != float64(3) || req["parse_mode"] != ParseMode {
+		t.Errorf("request %v", req)
+	}
+
+}
+
+func TestSendVideoNote(t *testing.T) {
+
+	var form *multipart.Form
+	var req map[string]interface{}
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				t.Error(err)
+			}
+			form = r.MultipartForm
+		} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"video_note":{"file_id":"note","length":240}}}`))
+	})
+
+	msg, err := SendVideoNoteFile(SendVideoNoteFileRequest{ChatId: "1", Length: 240, Duration: 5 * time.Second, VideoNote: strings.NewReader("mp4"), Thumb: strings.NewReader("jpeg")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.VideoNote.FileId != "note" || msg.VideoNote.Length != 240 {
+		t.Errorf("VideoNote %+v", msg.VideoNote)
+	}
+	if v := form.Value; v["length"][0] != "240" || v["duration"][0] != "5" {
+		t.Errorf("form values %v", v)
+	}
+	if f := form.File["video_note"]; len(f) != 1 || f[0].Filename != "video_note.mp4" {
+		t.Errorf("form file video_note %+v", f)
+	}
+	if f := form.File["thumbnail"]; len(f) != 1 || f[0].Filename != "video_note.jpg" {
+		t.Errorf("form file thumbnail %+v", f)
+	}
+
+	if _, err := SendVideoNote(SendVideoNoteRequest{ChatId: "1", VideoNote: "note", Length: 240, Duration: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if req["video_note"] != "note" || req["length"] != float64(240) || req["duration"] != float64(5) {
+		t.Errorf("request %v", req)
+	}
+
+}
+
+func TestSendMediaGroup(t *testing.T) {
+
+	var form *multipart.Form
+	testApi(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+		}
+		form = r.MultipartForm
+		w.Write([]byte(`{"ok":true,"result":[{"message_id":1},{"message_id":2}]}`))
+	})
+
+	if _, err := SendMediaGroup(SendMediaGroupRequest{ChatId: "1", Media: []InputMedia{InputMediaPhoto{Media: "photo"}}}); err == nil {
+		t.Errorf("no error for one item")
+	}
+	if form != nil {
+		t.Errorf("request sent for one item")
+	}
+
+	mm, err := SendMediaGroup(SendMediaGroupRequest{ChatId: "1", Media: []InputMedia{
+		InputMediaPhoto{File: strings.NewReader("png"), FileName: "a.png", Caption: "a\\."},
+		InputMediaVideo{Media: "video", Thumb: strings.NewReader("jpeg"), Width: 640, Height: 480},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mm) != 2 || mm[1].Id != "2" {
+		t.Errorf("messages %+v", mm)
+	}
+
+	var media []map[string]interface{}
+	if err := json.Unmarshal([]byte(form.Value["media"][0]), &media); err != nil {
+		t.Fatal(err)
+	}
+	if len(media) != 2 ||
+		media[0]["type"] != "photo" || media[0]["media"] != "attach://file0" ||
+		media[0]["caption"] != "a\\." || media[0]["parse_mode"] != ParseMode ||
+		media[1]["type"] != "video" || media[1]["media"] != "video" || media[1]["thumbnail"] != "attach://thumbnail1" ||
+		media[1]["width"] != float64(640) || media[1]["height"] != float64(480) {
+		t.Errorf("media %v", media)
+	}
+	if f := form.File["file0"]; len(f) != 1 || f[0].Filename != "a.png" {
+		t.Errorf("form file file0 %+v", f)
+	}
+	if f := form.File["thumbnail1"]; len(f) != 1 {
+		t.Errorf("form file thumbnail1 %+v", f)
+	}
+	if len(form.File) != 2 {
+		t.Errorf("form files %v", form.File)
+	}
+
+}
+
+func TestUpdateMessageUnmarshal(t *testing.T) {
+
+	// getUpdates result as returned by the bot api
+	fixture := `[
+{"update_id":900000001,"message":{"message_id":512,"from":{"id":111,"is_bot":false,"first_name":"Ann","username":"ann","language_code":"en"},
+"chat":{"id":-1001234567890,"title":"Ops","type":"supergroup"},"date":1760000000,
+"forward_origin":{"type":"channel","chat":{"id":-1009876543210,"title":"News","username":"news","type":"channel"},"message_id":77,"date":1759990000},
+"reply_to_message":{"message_id":510,"from":{"id":222,"is_bot":false,"first_name":"Bob"},"chat":{"id":-1001234567890,"title":"Ops","type":"supergroup"},"date":1759999000,
+"sticker":{"width":512,"height":512,"emoji":"👍","set_name":"Hands","is_animated":false,"is_video":false,"type":"regular",
+"thumbnail":{"file_id":"AAMthumb","file_unique_id":"AQthumb","file_size":4000,"width":128,"height":128},"file_id":"CAACsticker","file_unique_id":"AgADsticker","file_size":20000}},
+"text":"deploy v1.2 done, see https://example.org","entities":[{"offset":0,"length":6,"type":"bold"},{"offset":22,"length":19,"type":"url"}],
+"reply_markup":{"inline_keyboard":[[{"text":"Logs","url":"https://example.org/logs"},{"text":"Rollback","callback_data":"rollback:1.2"}]]}}},
+{"update_id":900000002,"message":{"message_id":513,"from":{"id":111,"is_bot":false,"first_name":"Ann"},"chat":{"id":-1001234567890,"title":"Ops","type":"supergroup"},"date":1760000100,
+"poll":{"id":"5400000000000000001","question":"Deploy now?","options":[{"text":"yes","voter_count":2},{"text":"no","voter_count":0}],
+"total_voter_count":2,"is_closed":false,"is_anonymous":true,"type":"regular","allows_multiple_answers":false}}}
+]`
+
+	var uu []Update
+	if err := json.Unmarshal([]byte(fixture), &uu); err != nil {
+		t.Fatal(err)
+	}
+	if len(uu) != 2 {
+		t.Fatalf("updates %+v", uu)
+	}
+
+	m := &uu[0].Message
+	if m.MessageId != 512 || m.From == nil || m.From.Username != "ann" || m.Chat.Id != -1001234567890 || m.SenderChat != nil {
+		t.Errorf("message %+v from %+v", m, m.From)
+	}
+	if o := m.ForwardOrigin; o == nil || o.Type != "channel" || o.Chat == nil || o.Chat.Username != "news" || o.MessageId != 77 || o.SenderUser != nil {
+		t.Errorf("forward_origin %+v", o)
+	}
+	if r := m.ReplyToMessage; r == nil || r.MessageId != 510 || r.Sticker == nil || r.Sticker.Emoji != "👍" || r.Sticker.Thumb.FileId != "AAMthumb" || r.Text != "" {
+		t.Errorf("reply_to_message %+v", r)
+	}
+	if len(m.Entities) != 2 || m.Entities[0].Type != EntityTypeBold || m.Entities[1].Type != EntityTypeUrl || m.Entities[1].Text(m.Text) != "https://example.org" {
+		t.Errorf("entities %+v", m.Entities)
+	}
+	if k := m.ReplyMarkup; k == nil || len(k.InlineKeyboard) != 1 || len(k.InlineKeyboard[0]) != 2 ||
+		k.InlineKeyboard[0][0].Url != "https://example.org/logs" || k.InlineKeyboard[0][1].CallbackData != "rollback:1.2" {
+		t.Errorf("reply_markup %+v", k)
+	}
+	if m.Audio != nil || m.Document != nil || m.Poll != nil || m.Location != nil {
+		t.Errorf("unexpected media in message %+v", m)
+	}
+
+	p := uu[1].Message.Poll
+	if p == nil || p.Question != "Deploy now?" || len(p.Options) != 2 || p.Options[0].VoterCount != 2 || !p.IsAnonymous || p.CorrectOptionId != nil {
+		t.Errorf("poll %+v", p)
 	}
 
 }
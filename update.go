@@ -0,0 +1,129 @@
+package tg
+
+// https://core.telegram.org/bots/api#update
+// https://core.telegram.org/bots/api#getupdates allowed_updates
+
+type UpdateType string
+
+const (
+	UpdateTypeUnknown UpdateType = ""
+
+	UpdateTypeMessage           UpdateType = "message"
+	UpdateTypeEditedMessage     UpdateType = "edited_message"
+	UpdateTypeChannelPost       UpdateType = "channel_post"
+	UpdateTypeEditedChannelPost UpdateType = "edited_channel_post"
+
+	UpdateTypeMessageReaction      UpdateType = "message_reaction"
+	UpdateTypeMessageReactionCount UpdateType = "message_reaction_count"
+
+	UpdateTypeInlineQuery        UpdateType = "inline_query"
+	UpdateTypeChosenInlineResult UpdateType = "chosen_inline_result"
+	UpdateTypeCallbackQuery      UpdateType = "callback_query"
+
+	UpdateTypePoll       UpdateType = "poll"
+	UpdateTypePollAnswer UpdateType = "poll_answer"
+
+	UpdateTypeMyChatMember    UpdateType = "my_chat_member"
+	UpdateTypeChatMember      UpdateType = "chat_member"
+	UpdateTypeChatJoinRequest UpdateType = "chat_join_request"
+)
+
+// Type tells which of the optional update fields is present.
+func (u *Update) Type() UpdateType {
+	switch {
+	case u.Message.MessageId != 0:
+		return UpdateTypeMessage
+	case u.EditedMessage.MessageId != 0:
+		return UpdateTypeEditedMessage
+	case u.ChannelPost.MessageId != 0:
+		return UpdateTypeChannelPost
+	case u.EditedChannelPost.MessageId != 0:
+		return UpdateTypeEditedChannelPost
+	case u.MessageReaction != nil:
+		return UpdateTypeMessageReaction
+	case u.MessageReactionCount != nil:
+		return UpdateTypeMessageReactionCount
+	case u.InlineQuery != nil:
+		return UpdateTypeInlineQuery
+	case u.ChosenInlineResult != nil:
+		return UpdateTypeChosenInlineResult
+	case u.CallbackQuery != nil:
+		return UpdateTypeCallbackQuery
+	case u.Poll != nil:
+		return UpdateTypePoll
+	case u.PollAnswer != nil:
+		return UpdateTypePollAnswer
+	case u.MyChatMember.Date != 0:
+		return UpdateTypeMyChatMember
+	case u.ChatMember.Date != 0:
+		return UpdateTypeChatMember
+	case u.ChatJoinRequest != nil:
+		return UpdateTypeChatJoinRequest
+	}
+	return UpdateTypeUnknown
+}
+
+// EffectiveMessage returns the new or edited message or channel post,
+// or the message of the callback query, or nil.
+func (u *Update) EffectiveMessage() *Message {
+	switch u.Type() {
+	case UpdateTypeMessage:
+		return &u.Message
+	case UpdateTypeEditedMessage:
+		return &u.EditedMessage
+	case UpdateTypeChannelPost:
+		return &u.ChannelPost
+	case UpdateTypeEditedChannelPost:
+		return &u.EditedChannelPost
+	case UpdateTypeCallbackQuery:
+		return u.CallbackQuery.Message
+	}
+	return nil
+}
+
+// EffectiveChat returns the chat the update happened in or nil.
+func (u *Update) EffectiveChat() *Chat {
+	switch u.Type() {
+	case UpdateTypeMessageReaction:
+		return &u.MessageReaction.Chat
+	case UpdateTypeMessageReactionCount:
+		return &u.MessageReactionCount.Chat
+	case UpdateTypeMyChatMember:
+		return &u.MyChatMember.Chat
+	case UpdateTypeChatMember:
+		return &u.ChatMember.Chat
+	case UpdateTypeChatJoinRequest:
+		return &u.ChatJoinRequest.Chat
+	}
+	if m := u.EffectiveMessage(); m != nil {
+		return &m.Chat
+	}
+	return nil
+}
+
+// EffectiveUser returns the user who caused the update or nil,
+// for example for channel posts and anonymous reactions.
+func (u *Update) EffectiveUser() *User {
+	switch u.Type() {
+	case UpdateTypeMessageReaction:
+		return u.MessageReaction.User
+	case UpdateTypeInlineQuery:
+		return &u.InlineQuery.From
+	case UpdateTypeChosenInlineResult:
+		return &u.ChosenInlineResult.From
+	case UpdateTypeCallbackQuery:
+		return &u.CallbackQuery.From
+	case UpdateTypePollAnswer:
+		return u.PollAnswer.User
+	case UpdateTypeMyChatMember:
+		return &u.MyChatMember.From
+	case UpdateTypeChatMember:
+		return &u.ChatMember.From
+	case UpdateTypeChatJoinRequest:
+		return &u.ChatJoinRequest.From
+	}
+	if m := u.EffectiveMessage(); m != nil {
+		return m.From
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package tg
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUpdateType(t *testing.T) {
+
+	for _, tc := range []struct {
+		json   string
+		typ    UpdateType
+		chatid int64
+		userid int64
+	}{
+		{`{"update_id":1,"message":{"message_id":1,"from":{"id":10},"chat":{"id":20}}}`, UpdateTypeMessage, 20, 10},
+		{`{"update_id":2,"edited_channel_post":{"message_id":1,"chat":{"id":21}}}`, UpdateTypeEditedChannelPost, 21, 0},
+		{`{"update_id":3,"callback_query":{"id":"q","from":{"id":12},"message":{"message_id":5,"chat":{"id":22}}}}`, UpdateTypeCallbackQuery, 22, 12},
+		{`{"update_id":4,"my_chat_member":{"chat":{"id":23},"from":{"id":13},"date":1}}`, UpdateTypeMyChatMember, 23, 13},
+		{`{"update_id":5,"message_reaction":{"chat":{"id":24},"message_id":1,"user":{"id":14},"date":1}}`, UpdateTypeMessageReaction, 24, 14},
+		{`{"update_id":6,"inline_query":{"id":"q","from":{"id":15},"query":""}}`, UpdateTypeInlineQuery, 0, 15},
+		{`{"update_id":7}`, UpdateTypeUnknown, 0, 0},
+	} {
+		var u Update
+		if err := json.Unmarshal([]byte(tc.json), &u); err != nil {
+			t.Fatal(err)
+		}
+		if typ := u.Type(); typ != tc.typ {
+			t.Errorf("update %d Type %q expected %q", u.UpdateId, typ, tc.typ)
+		}
+		if c := u.EffectiveChat(); (c == nil) != (tc.chatid == 0) || c != nil && c.Id != tc.chatid {
+			t.Errorf("update %d EffectiveChat %#v expected id %d", u.UpdateId, c, tc.chatid)
+		}
+		if usr := u.EffectiveUser(); (usr == nil) != (tc.userid == 0) || usr != nil && usr.Id != tc.userid {
+			t.Errorf("update %d EffectiveUser %#v expected id %d", u.UpdateId, usr, tc.userid)
+		}
+	}
+
+}